@@ -6,11 +6,13 @@ import (
 
 	"github.com/Bendomey/nucleo-go"
 	"github.com/Bendomey/nucleo-go/cache"
+	"github.com/Bendomey/nucleo-go/circuitbreaker"
 	"github.com/Bendomey/nucleo-go/context"
 	bus "github.com/Bendomey/nucleo-go/emitter"
 	"github.com/Bendomey/nucleo-go/metrics"
 	"github.com/Bendomey/nucleo-go/middleware"
 	"github.com/Bendomey/nucleo-go/payload"
+	"github.com/Bendomey/nucleo-go/ratelimit"
 	"github.com/Bendomey/nucleo-go/registry"
 	"github.com/Bendomey/nucleo-go/serializer"
 	"github.com/Bendomey/nucleo-go/service"
@@ -32,6 +34,8 @@ type ServiceBroker struct {
 
 	cache cache.Cache
 
+	circuitBreaker *circuitbreaker.CircuitBreaker
+
 	serializer *serializer.Serializer
 
 	services []*service.Service
@@ -368,6 +372,10 @@ func (broker *ServiceBroker) Stop() {
 
 	broker.registry.Stop()
 
+	if broker.cache != nil {
+		broker.cache.Close()
+	}
+
 	broker.started = false
 	broker.broadcastLocal("$broker.stopped")
 
@@ -457,6 +465,20 @@ func (broker *ServiceBroker) Broadcast(event string, params interface{}, groups
 	broker.registry.BroadcastEvent(newContext)
 }
 
+// Ping send a PING to the remote node and return a channel which will deliver the round trip (elapsedTime)
+// and the clock difference between the nodes (timeDiff) in milliseconds. The default timeout is Config.RequestTimeout.
+func (broker *ServiceBroker) Ping(nodeID string, timeout ...time.Duration) chan nucleo.Payload {
+	broker.logger.Traceln("Broker - Ping() nodeID: ", nodeID)
+	if !broker.IsStarted() {
+		panic(errors.New("Broker must be started before sending pings :("))
+	}
+	pingTimeout := broker.config.RequestTimeout
+	if len(timeout) > 0 && timeout[0] > 0 {
+		pingTimeout = timeout[0]
+	}
+	return broker.registry.Ping(nodeID, pingTimeout)
+}
+
 func (broker *ServiceBroker) IsStarted() bool {
 	return broker.started
 }
@@ -497,6 +519,22 @@ func (broker *ServiceBroker) registerInternalMiddlewares() {
 
 	// Validation
 	broker.middlewares.Add(broker.validator.Middlewares())
+
+	// Rate Limit
+	broker.middlewares.Add(ratelimit.Middlewares(broker.localActionSettings))
+
+	// Circuit Breaker
+	broker.circuitBreaker = circuitbreaker.New(broker.localBus, broker.logger.WithField("middleware", "circuit-breaker"))
+	broker.middlewares.Add(broker.circuitBreaker.Middlewares())
+}
+
+// localActionSettings return the settings of a local action and of its service.
+func (broker *ServiceBroker) localActionSettings(actionName string) (map[string]interface{}, string, map[string]interface{}) {
+	actionEntry := broker.registry.LocalAction(actionName)
+	if actionEntry == nil {
+		return nil, "", nil
+	}
+	return actionEntry.Action().Settings(), actionEntry.Service().FullName(), actionEntry.Service().Settings()
 }
 
 func (broker *ServiceBroker) init() {
@@ -523,6 +561,7 @@ func (broker *ServiceBroker) init() {
 	broker.delegates = broker.createDelegates()
 	broker.registry = registry.CreateRegistry(broker.id, broker.delegates)
 	broker.localNode = broker.registry.LocalNode()
+	broker.cache = broker.registry.Cache()
 	broker.rootContext = context.BrokerContext(broker.delegates)
 
 }
@@ -569,6 +608,9 @@ func (broker *ServiceBroker) createDelegates() *nucleo.BrokerDelegates {
 		MiddlewareHandler: broker.middlewares.CallHandlers,
 		PublishServices:   broker.PublishServices,
 		WaitFor:           broker.WaitFor,
+		EndpointAvailable: func(actionName string, nodeID string) bool {
+			return broker.circuitBreaker == nil || broker.circuitBreaker.IsAvailable(actionName, nodeID)
+		},
 	}
 }
 
@@ -18,6 +18,12 @@ func mergeConfigs(baseConfig nucleo.Config, userConfig []*nucleo.Config) nucleo.
 			if config.Services != nil {
 				baseConfig.Services = mergeMaps(baseConfig.Services, config.Services)
 			}
+			if config.TransporterOptions != nil {
+				baseConfig.TransporterOptions = mergeMaps(baseConfig.TransporterOptions, config.TransporterOptions)
+			}
+			if config.Metadata != nil {
+				baseConfig.Metadata = mergeMaps(baseConfig.Metadata, config.Metadata)
+			}
 
 			if config.LogLevel != "" {
 				baseConfig.LogLevel = config.LogLevel
@@ -37,9 +43,15 @@ func mergeConfigs(baseConfig nucleo.Config, userConfig []*nucleo.Config) nucleo.
 			if config.TransporterFactory != nil {
 				baseConfig.TransporterFactory = config.TransporterFactory
 			}
+			if config.Strategy != "" {
+				baseConfig.Strategy = config.Strategy
+			}
 			if config.StrategyFactory != nil {
 				baseConfig.StrategyFactory = config.StrategyFactory
 			}
+			if config.Cacher != nil {
+				baseConfig.Cacher = config.Cacher
+			}
 			if config.DisableInternalMiddlewares {
 				baseConfig.DisableInternalMiddlewares = config.DisableInternalMiddlewares
 			}
@@ -64,6 +76,18 @@ func mergeConfigs(baseConfig nucleo.Config, userConfig []*nucleo.Config) nucleo.
 			if config.RequestTimeout != 0 {
 				baseConfig.RequestTimeout = config.RequestTimeout
 			}
+			if config.PreferLocal != nil {
+				baseConfig.PreferLocal = config.PreferLocal
+			}
+			if config.MaxCallLevel != 0 {
+				baseConfig.MaxCallLevel = config.MaxCallLevel
+			}
+			baseConfig.RetryPolicy = mergeRetryPolicy(baseConfig.RetryPolicy, config.RetryPolicy)
+			baseConfig.CircuitBreaker = mergeCircuitBreaker(baseConfig.CircuitBreaker, config.CircuitBreaker)
+			baseConfig.Bulkhead = mergeBulkhead(baseConfig.Bulkhead, config.Bulkhead)
+			baseConfig.RateLimit = mergeRateLimit(baseConfig.RateLimit, config.RateLimit)
+			baseConfig.Latency = mergeLatency(baseConfig.Latency, config.Latency)
+			baseConfig.Shard = mergeShard(baseConfig.Shard, config.Shard)
 
 			if config.Namespace != "" {
 				baseConfig.Namespace = config.Namespace
@@ -72,3 +96,102 @@ func mergeConfigs(baseConfig nucleo.Config, userConfig []*nucleo.Config) nucleo.
 	}
 	return baseConfig
 }
+
+func mergeRetryPolicy(basePolicy, userPolicy nucleo.RetryPolicy) nucleo.RetryPolicy {
+	if userPolicy.Enabled {
+		basePolicy.Enabled = userPolicy.Enabled
+	}
+	if userPolicy.Retries != 0 {
+		basePolicy.Retries = userPolicy.Retries
+	}
+	if userPolicy.Delay != 0 {
+		basePolicy.Delay = userPolicy.Delay
+	}
+	if userPolicy.MaxDelay != 0 {
+		basePolicy.MaxDelay = userPolicy.MaxDelay
+	}
+	if userPolicy.Factor != 0 {
+		basePolicy.Factor = userPolicy.Factor
+	}
+	if userPolicy.Check != nil {
+		basePolicy.Check = userPolicy.Check
+	}
+	return basePolicy
+}
+
+func mergeCircuitBreaker(baseOptions, userOptions nucleo.CircuitBreaker) nucleo.CircuitBreaker {
+	if userOptions.Enabled {
+		baseOptions.Enabled = userOptions.Enabled
+	}
+	if userOptions.Threshold != 0 {
+		baseOptions.Threshold = userOptions.Threshold
+	}
+	if userOptions.MinRequestCount != 0 {
+		baseOptions.MinRequestCount = userOptions.MinRequestCount
+	}
+	if userOptions.WindowTime != 0 {
+		baseOptions.WindowTime = userOptions.WindowTime
+	}
+	if userOptions.HalfOpenTime != 0 {
+		baseOptions.HalfOpenTime = userOptions.HalfOpenTime
+	}
+	if userOptions.Check != nil {
+		baseOptions.Check = userOptions.Check
+	}
+	return baseOptions
+}
+
+func mergeBulkhead(baseOptions, userOptions nucleo.Bulkhead) nucleo.Bulkhead {
+	if userOptions.Enabled {
+		baseOptions.Enabled = userOptions.Enabled
+	}
+	if userOptions.Concurrency != 0 {
+		baseOptions.Concurrency = userOptions.Concurrency
+	}
+	if userOptions.MaxQueueSize != 0 {
+		baseOptions.MaxQueueSize = userOptions.MaxQueueSize
+	}
+	return baseOptions
+}
+
+func mergeRateLimit(baseOptions, userOptions nucleo.RateLimit) nucleo.RateLimit {
+	if userOptions.Enabled {
+		baseOptions.Enabled = userOptions.Enabled
+	}
+	if userOptions.Rate != 0 {
+		baseOptions.Rate = userOptions.Rate
+	}
+	if userOptions.Burst != 0 {
+		baseOptions.Burst = userOptions.Burst
+	}
+	if userOptions.Wait {
+		baseOptions.Wait = userOptions.Wait
+	}
+	if userOptions.MaxWait != 0 {
+		baseOptions.MaxWait = userOptions.MaxWait
+	}
+	return baseOptions
+}
+
+func mergeLatency(baseOptions, userOptions nucleo.Latency) nucleo.Latency {
+	if userOptions.Enabled {
+		baseOptions.Enabled = userOptions.Enabled
+	}
+	if userOptions.PingInterval != 0 {
+		baseOptions.PingInterval = userOptions.PingInterval
+	}
+	if userOptions.Alpha != 0 {
+		baseOptions.Alpha = userOptions.Alpha
+	}
+	return baseOptions
+}
+
+func mergeShard(baseOptions, userOptions nucleo.Shard) nucleo.Shard {
+	if userOptions.Key != "" {
+		baseOptions.Key = userOptions.Key
+	}
+	if userOptions.VirtualNodes != 0 {
+		baseOptions.VirtualNodes = userOptions.VirtualNodes
+	}
+	return baseOptions
+}
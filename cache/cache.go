@@ -1,4 +1,165 @@
 package cache
 
+import (
+	"fmt"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/serializer"
+	log "github.com/sirupsen/logrus"
+)
+
+// Cache is the contract implemented by all cachers (e.g. memory).
+// Cached values are nucleo.Payload instances stored under a key generated by GetCacheKey.
 type Cache interface {
+	// Get, Set, Del, Clean and Close are declared by nucleo.Cacher, the type of Config.Cacher.
+	nucleo.Cacher
+	// GetWithTTL works like Get and also return the remaining time to live of the entry. Zero means the entry never expires.
+	GetWithTTL(key string) (nucleo.Payload, time.Duration, bool)
+	// Lock acquire the lock of the key, waiting at most timeout for it to be released by other callers.
+	// Return the function that releases the lock and false when the lock could not be acquired in time.
+	Lock(key string, timeout time.Duration) (unlock func(), acquired bool)
+	// LockOptions return the locking configuration of the cacher.
+	LockOptions() LockOptions
+	// GetCacheKey generate the cache key for an action call.
+	GetCacheKey(actionName string, params nucleo.Payload, meta nucleo.Payload, keys []string) string
+
+	SetLogger(logger *log.Entry)
+	SetSerializer(serializer serializer.Serializer)
+}
+
+// LockOptions configure the cache stampede protection.
+// When enabled only one caller computes a missing value, while the other callers wait for it.
+type LockOptions struct {
+	Enabled bool
+	// TTL maximum time a caller waits for the lock, also used as lease duration by distributed cachers.
+	TTL time.Duration
+	// StaleTime entries are kept this extra time after they expire. Stale entries are returned
+	// while the value is refreshed in the background. Zero disables stale-while-revalidate.
+	StaleTime time.Duration
+}
+
+var DefaultLockOptions = LockOptions{
+	Enabled:   false,
+	TTL:       15 * time.Second,
+	StaleTime: 0,
+}
+
+// MergeLockOptions merge the user lock options with the base options.
+func MergeLockOptions(baseConfig LockOptions, userConfig LockOptions) LockOptions {
+	if userConfig.Enabled {
+		baseConfig.Enabled = userConfig.Enabled
+	}
+	if userConfig.TTL != 0 {
+		baseConfig.TTL = userConfig.TTL
+	}
+	if userConfig.StaleTime != 0 {
+		baseConfig.StaleTime = userConfig.StaleTime
+	}
+	return baseConfig
+}
+
+// EntryTTL return the time to live used to store an entry. When stale-while-revalidate is enabled
+// the entry is kept StaleTime longer, so it can be served while it is refreshed.
+func (options LockOptions) EntryTTL(ttl time.Duration) time.Duration {
+	if ttl > 0 && options.Enabled && options.StaleTime > 0 {
+		return ttl + options.StaleTime
+	}
+	return ttl
+}
+
+// IsStale check if an entry with the remaining ttl has expired and is only kept to be served while refreshed.
+func (options LockOptions) IsStale(remaining time.Duration) bool {
+	return options.Enabled && options.StaleTime > 0 && remaining > 0 && remaining <= options.StaleTime
+}
+
+// ActionOptions is the cache configuration of an action, taken from Action.Settings["cache"].
+type ActionOptions struct {
+	Keys []string
+	TTL  time.Duration
+}
+
+// ActionSettings parse the "cache" entry of the action settings.
+// Accepted values are true (cache using all params) or a map with "keys" ([]string) and "ttl" (time.Duration or seconds).
+func ActionSettings(settings map[string]interface{}) (ActionOptions, bool) {
+	options := ActionOptions{}
+	if settings == nil {
+		return options, false
+	}
+	value, exists := settings["cache"]
+	if !exists || value == nil {
+		return options, false
+	}
+	if enabled, isBool := value.(bool); isBool {
+		return options, enabled
+	}
+	values, isMap := value.(map[string]interface{})
+	if !isMap {
+		return options, false
+	}
+	if enabled, exists := values["enabled"]; exists && enabled == false {
+		return options, false
+	}
+	options.Keys = toStringList(values["keys"])
+	options.TTL = ToDuration(values["ttl"], time.Second)
+	return options, true
+}
+
+// ToDuration convert a setting value into a time.Duration.
+// Numeric values are multiplied by the unit (e.g. ttl in seconds).
+func ToDuration(value interface{}, unit time.Duration) time.Duration {
+	switch v := value.(type) {
+	case time.Duration:
+		return v
+	case int:
+		return time.Duration(v) * unit
+	case int64:
+		return time.Duration(v) * unit
+	case float64:
+		return time.Duration(v * float64(unit))
+	case string:
+		d, err := time.ParseDuration(v)
+		if err == nil {
+			return d
+		}
+	}
+	return 0
+}
+
+func toStringList(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		result := make([]string, len(v))
+		for index, item := range v {
+			result[index] = fmt.Sprint(item)
+		}
+		return result
+	case string:
+		return []string{v}
+	}
+	return nil
+}
+
+// Match check if the key matches the pattern. The wildcard * matches any sequence of characters.
+func Match(pattern, key string) bool {
+	if pattern == "" || pattern == "*" || pattern == "**" {
+		return true
+	}
+	return matchRunes([]rune(pattern), []rune(key))
+}
+
+func matchRunes(pattern, key []rune) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == '*' {
+			return matchRunes(pattern[1:], key) || (len(key) > 0 && matchRunes(pattern, key[1:]))
+		}
+		if len(key) == 0 || key[0] != pattern[0] {
+			return false
+		}
+		key = key[1:]
+		pattern = pattern[1:]
+	}
+	return len(key) == 0
 }
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/payload"
+	"github.com/Bendomey/nucleo-go/serializer"
+	log "github.com/sirupsen/logrus"
+)
+
+const metaPrefix = "#"
+
+// hashLength size of the (truncated) SHA-256 hex digest used for long keys.
+const hashLength = 32
+
+// KeyGenerator generate deterministic cache keys for action calls.
+// The same params and meta always produce the same key, independent of the map ordering.
+type KeyGenerator struct {
+	Serializer serializer.Serializer
+	// MaxKeyLength when the params part of the key is longer than this value it is hashed. Zero disables hashing.
+	MaxKeyLength int
+}
+
+var defaultSerializer serializer.Serializer
+var defaultSerializerOnce sync.Once
+
+// jsonSerializer return the JSON serializer shared by the key generators created without a serializer.
+func jsonSerializer() serializer.Serializer {
+	defaultSerializerOnce.Do(func() {
+		defaultSerializer = serializer.CreateJSONSerializer(log.WithField("serializer", "json"))
+	})
+	return defaultSerializer
+}
+
+func CreateKeyGenerator(maxKeyLength int) *KeyGenerator {
+	return &KeyGenerator{Serializer: jsonSerializer(), MaxKeyLength: maxKeyLength}
+}
+
+// Key generate the cache key for the action call.
+// When keys are empty all params are used, otherwise only the listed keys. Keys starting with #
+// (e.g. #meta.userID or #userID) are read from the context meta instead of the params.
+func (generator *KeyGenerator) Key(actionName string, params nucleo.Payload, meta nucleo.Payload, keys []string) string {
+	var body string
+	if len(keys) == 0 {
+		if params == nil || !params.Exists() {
+			return actionName
+		}
+		body = generator.serialize(params)
+	} else {
+		values := make([]string, len(keys))
+		for index, key := range keys {
+			values[index] = generator.serialize(resolveKey(key, params, meta))
+		}
+		body = strings.Join(values, "|")
+	}
+	return actionName + ":" + generator.hash(body)
+}
+
+// resolveKey read the value of the key from the params or from the meta when the key is a meta reference.
+func resolveKey(key string, params nucleo.Payload, meta nucleo.Payload) nucleo.Payload {
+	source := params
+	if strings.HasPrefix(key, metaPrefix) {
+		source = meta
+		key = strings.TrimPrefix(strings.TrimPrefix(key, metaPrefix), "meta.")
+	}
+	if source == nil || !source.Exists() {
+		return nil
+	}
+	return source.Get(key)
+}
+
+// serialize transform the value into a string. Maps and arrays are serialized to JSON with sorted keys.
+func (generator *KeyGenerator) serialize(value nucleo.Payload) string {
+	if value == nil || !value.Exists() {
+		return "null"
+	}
+	if !value.IsMap() && !value.IsArray() {
+		return value.String()
+	}
+	// Value() returns the plain go values, so the JSON is generated again with the map keys sorted,
+	// even when the source is a JSON payload received from another node.
+	return generator.serializer().PayloadToString(payload.New(value.Value()))
+}
+
+func (generator *KeyGenerator) serializer() serializer.Serializer {
+	if generator.Serializer == nil {
+		return jsonSerializer()
+	}
+	return generator.Serializer
+}
+
+// hash replace the end of the key with a truncated SHA-256 digest when it is longer than MaxKeyLength (in bytes).
+// The kept part is cut on a rune boundary, and the digest is shortened when MaxKeyLength is under its length.
+func (generator *KeyGenerator) hash(body string) string {
+	if generator.MaxKeyLength <= 0 || len(body) <= generator.MaxKeyLength {
+		return body
+	}
+	sum := sha256.Sum256([]byte(body))
+	digest := hex.EncodeToString(sum[:])[:hashLength]
+	if generator.MaxKeyLength < hashLength {
+		return digest[:generator.MaxKeyLength]
+	}
+	head := generator.MaxKeyLength - hashLength
+	for head > 0 && !utf8.RuneStart(body[head]) {
+		head--
+	}
+	return body[:head] + digest
+}
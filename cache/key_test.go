@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Bendomey/nucleo-go/payload"
+)
+
+func TestKeyIsDeterministic(t *testing.T) {
+	generator := CreateKeyGenerator(0)
+	first := generator.Key("users.get", payload.New(map[string]interface{}{"id": 1, "fields": []string{"name"}, "limit": 10}), nil, nil)
+	second := generator.Key("users.get", payload.New(map[string]interface{}{"limit": 10, "fields": []string{"name"}, "id": 1}), nil, nil)
+	if first != second {
+		t.Fatalf("expected the same key for the same params, got %q and %q", first, second)
+	}
+	meta := payload.New(map[string]interface{}{"userID": "u1"})
+	if key := generator.Key("users.get", payload.New(map[string]interface{}{"id": 1}), meta, []string{"id", "#meta.userID"}); key != "users.get:1|u1" {
+		t.Fatalf("expected the listed params and meta in the key, got %q", key)
+	}
+}
+
+func TestHash(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxKeyLength int
+		body         string
+	}{
+		{"short key is kept", 100, "short"},
+		{"long key", 40, strings.Repeat("a", 100)},
+		{"max under the hash length", 8, strings.Repeat("a", 100)},
+		{"max of one byte", 1, strings.Repeat("a", 100)},
+		{"multi byte runes at the cut", 35, strings.Repeat("é", 50)},
+		{"multi byte runes of three bytes", 36, strings.Repeat("日本", 30)},
+	}
+	for _, test := range tests {
+		generator := CreateKeyGenerator(test.maxKeyLength)
+		key := generator.hash(test.body)
+		if len(test.body) <= test.maxKeyLength {
+			if key != test.body {
+				t.Errorf("%s: expected the body to be kept, got %q", test.name, key)
+			}
+			continue
+		}
+		if len(key) > test.maxKeyLength {
+			t.Errorf("%s: expected at most %d bytes, got %d: %q", test.name, test.maxKeyLength, len(key), key)
+		}
+		if !utf8.ValidString(key) {
+			t.Errorf("%s: expected the key to be cut on a rune boundary, got %q", test.name, key)
+		}
+		if other := generator.hash(test.body + "b"); other == key {
+			t.Errorf("%s: expected different bodies to have different keys", test.name)
+		}
+	}
+}
+
+// the key generator of a cacher is shared by all the calls, run with -race.
+func TestKeyConcurrentCalls(t *testing.T) {
+	generator := &KeyGenerator{}
+	params := payload.New(map[string]interface{}{"id": 1, "tags": []string{"a", "b"}})
+	var group sync.WaitGroup
+	keys := make([]string, 10)
+	for index := range keys {
+		group.Add(1)
+		go func(index int) {
+			defer group.Done()
+			keys[index] = generator.Key("users.get", params, nil, nil)
+		}(index)
+	}
+	group.Wait()
+	for _, key := range keys {
+		if key != keys[0] {
+			t.Fatalf("expected the same key, got %q and %q", keys[0], key)
+		}
+	}
+}
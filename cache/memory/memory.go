@@ -0,0 +1,294 @@
+package memory
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/cache"
+	"github.com/Bendomey/nucleo-go/serializer"
+	log "github.com/sirupsen/logrus"
+)
+
+var DefaultConfig = MemoryOptions{
+	Max:           1000,
+	TTL:           0,
+	CheckInterval: 30 * time.Second,
+	MaxKeyLength:  0,
+	Lock:          cache.DefaultLockOptions,
+}
+
+type MemoryOptions struct {
+	// Max number of entries kept in the cache. When full the least recently used entry is evicted.
+	Max int
+	// TTL default time to live of the entries. Zero means entries never expire.
+	TTL time.Duration
+	// CheckInterval frequency used to remove expired entries.
+	CheckInterval time.Duration
+	// MaxKeyLength keys longer than this are hashed. Zero keeps the full key.
+	MaxKeyLength int
+	// Lock per key locking used to avoid cache stampedes.
+	Lock cache.LockOptions
+
+	Logger *log.Entry
+}
+
+type entry struct {
+	key     string
+	value   nucleo.Payload
+	expires time.Time
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+// keyLock is the lock of a single key. The channel holds a value while the lock is taken,
+// so waiting callers can give up after a timeout.
+type keyLock struct {
+	channel chan bool
+	refs    int
+}
+
+// MemoryCacher is an in process LRU cache with per entry TTL.
+type MemoryCacher struct {
+	opts    *MemoryOptions
+	logger  *log.Entry
+	keygen  *cache.KeyGenerator
+	mutex   *sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	done    chan bool
+	closed  *sync.Once
+
+	locks      map[string]*keyLock
+	locksMutex *sync.Mutex
+}
+
+func mergeConfigs(baseConfig MemoryOptions, userConfig MemoryOptions) MemoryOptions {
+	if userConfig.Max != 0 {
+		baseConfig.Max = userConfig.Max
+	}
+	if userConfig.TTL != 0 {
+		baseConfig.TTL = userConfig.TTL
+	}
+	if userConfig.CheckInterval != 0 {
+		baseConfig.CheckInterval = userConfig.CheckInterval
+	}
+	if userConfig.MaxKeyLength != 0 {
+		baseConfig.MaxKeyLength = userConfig.MaxKeyLength
+	}
+	if userConfig.Logger != nil {
+		baseConfig.Logger = userConfig.Logger
+	}
+	baseConfig.Lock = cache.MergeLockOptions(baseConfig.Lock, userConfig.Lock)
+	return baseConfig
+}
+
+func CreateMemoryCacher(options ...MemoryOptions) cache.Cache {
+	opts := DefaultConfig
+	if len(options) > 0 {
+		opts = mergeConfigs(DefaultConfig, options[0])
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = log.WithField("cacher", "memory")
+	}
+	cacher := &MemoryCacher{
+		opts:    &opts,
+		logger:  logger,
+		keygen:  cache.CreateKeyGenerator(opts.MaxKeyLength),
+		mutex:   &sync.Mutex{},
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		done:    make(chan bool),
+		closed:  &sync.Once{},
+
+		locks:      make(map[string]*keyLock),
+		locksMutex: &sync.Mutex{},
+	}
+	if opts.CheckInterval > 0 {
+		go cacher.checkTTL()
+	}
+	return cacher
+}
+
+// checkTTL remove the expired entries on every CheckInterval until the cacher is closed.
+func (cacher *MemoryCacher) checkTTL() {
+	ticker := time.NewTicker(cacher.opts.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-cacher.done:
+			return
+		case now := <-ticker.C:
+			cacher.mutex.Lock()
+			for _, element := range cacher.entries {
+				if element.Value.(*entry).expired(now) {
+					cacher.removeElement(element)
+				}
+			}
+			cacher.mutex.Unlock()
+		}
+	}
+}
+
+func (cacher *MemoryCacher) removeElement(element *list.Element) {
+	cacher.lru.Remove(element)
+	delete(cacher.entries, element.Value.(*entry).key)
+}
+
+func (cacher *MemoryCacher) Get(key string) (nucleo.Payload, bool) {
+	value, _, found := cacher.GetWithTTL(key)
+	return value, found
+}
+
+func (cacher *MemoryCacher) GetWithTTL(key string) (nucleo.Payload, time.Duration, bool) {
+	cacher.mutex.Lock()
+	defer cacher.mutex.Unlock()
+
+	element, exists := cacher.entries[key]
+	if !exists {
+		cacher.logger.Traceln("Get() cache miss - key: ", key)
+		return nil, 0, false
+	}
+	item := element.Value.(*entry)
+	now := time.Now()
+	if item.expired(now) {
+		cacher.logger.Traceln("Get() entry expired - key: ", key)
+		cacher.removeElement(element)
+		return nil, 0, false
+	}
+	cacher.lru.MoveToFront(element)
+	cacher.logger.Traceln("Get() cache hit - key: ", key)
+	var ttl time.Duration
+	if !item.expires.IsZero() {
+		ttl = item.expires.Sub(now)
+	}
+	return item.value, ttl, true
+}
+
+func (cacher *MemoryCacher) Set(key string, value nucleo.Payload, ttl time.Duration) {
+	if ttl == 0 {
+		ttl = cacher.opts.TTL
+	}
+	ttl = cacher.opts.Lock.EntryTTL(ttl)
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+
+	cacher.mutex.Lock()
+	defer cacher.mutex.Unlock()
+
+	if element, exists := cacher.entries[key]; exists {
+		item := element.Value.(*entry)
+		item.value = value
+		item.expires = expires
+		cacher.lru.MoveToFront(element)
+		return
+	}
+	cacher.entries[key] = cacher.lru.PushFront(&entry{key, value, expires})
+	for cacher.opts.Max > 0 && cacher.lru.Len() > cacher.opts.Max {
+		oldest := cacher.lru.Back()
+		cacher.logger.Traceln("Set() max size reached, evicting key: ", oldest.Value.(*entry).key)
+		cacher.removeElement(oldest)
+	}
+}
+
+func (cacher *MemoryCacher) Del(key string) {
+	cacher.mutex.Lock()
+	defer cacher.mutex.Unlock()
+	if element, exists := cacher.entries[key]; exists {
+		cacher.removeElement(element)
+	}
+}
+
+func (cacher *MemoryCacher) Clean(pattern string) {
+	cacher.mutex.Lock()
+	defer cacher.mutex.Unlock()
+	cacher.logger.Debugln("Clean() pattern: ", pattern)
+	for key, element := range cacher.entries {
+		if cache.Match(pattern, key) {
+			cacher.removeElement(element)
+		}
+	}
+}
+
+// Lock acquire the in process lock of the key. A timeout of zero only tries to acquire the lock without waiting.
+func (cacher *MemoryCacher) Lock(key string, timeout time.Duration) (func(), bool) {
+	cacher.locksMutex.Lock()
+	lock, exists := cacher.locks[key]
+	if !exists {
+		lock = &keyLock{channel: make(chan bool, 1)}
+		cacher.locks[key] = lock
+	}
+	lock.refs++
+	cacher.locksMutex.Unlock()
+
+	acquired := false
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		select {
+		case lock.channel <- true:
+			acquired = true
+		case <-timer.C:
+		}
+		timer.Stop()
+	} else {
+		select {
+		case lock.channel <- true:
+			acquired = true
+		default:
+		}
+	}
+	if !acquired {
+		cacher.logger.Debugln("Lock() could not acquire lock - key: ", key, " timeout: ", timeout)
+		cacher.releaseLock(key, lock)
+		return func() {}, false
+	}
+	released := &sync.Once{}
+	return func() {
+		released.Do(func() {
+			<-lock.channel
+			cacher.releaseLock(key, lock)
+		})
+	}, true
+}
+
+// releaseLock remove the lock from the map when nobody holds or waits for it.
+func (cacher *MemoryCacher) releaseLock(key string, lock *keyLock) {
+	cacher.locksMutex.Lock()
+	defer cacher.locksMutex.Unlock()
+	lock.refs--
+	if lock.refs == 0 {
+		delete(cacher.locks, key)
+	}
+}
+
+func (cacher *MemoryCacher) LockOptions() cache.LockOptions {
+	return cacher.opts.Lock
+}
+
+func (cacher *MemoryCacher) GetCacheKey(actionName string, params nucleo.Payload, meta nucleo.Payload, keys []string) string {
+	return cacher.keygen.Key(actionName, params, meta, keys)
+}
+
+func (cacher *MemoryCacher) SetLogger(logger *log.Entry) {
+	if cacher.opts.Logger == nil {
+		cacher.logger = logger
+	}
+}
+
+// SetSerializer set the serializer used to generate the cache keys.
+// Values are kept in memory as nucleo.Payload, so they are not serialized.
+func (cacher *MemoryCacher) SetSerializer(serializer serializer.Serializer) {
+	cacher.keygen.Serializer = serializer
+}
+
+func (cacher *MemoryCacher) Close() {
+	cacher.closed.Do(func() {
+		close(cacher.done)
+	})
+}
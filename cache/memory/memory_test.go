@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Bendomey/nucleo-go/payload"
+	log "github.com/sirupsen/logrus"
+)
+
+func createTestCacher(t *testing.T, options MemoryOptions) *MemoryCacher {
+	options.Logger = log.WithField("cacher", "memory-test")
+	cacher := CreateMemoryCacher(options).(*MemoryCacher)
+	t.Cleanup(cacher.Close)
+	return cacher
+}
+
+func TestEviction(t *testing.T) {
+	tests := []struct {
+		name    string
+		max     int
+		steps   []string // "set <key>" or "get <key>"
+		evicted []string
+		kept    []string
+	}{
+		{
+			name:    "least recently set",
+			max:     2,
+			steps:   []string{"set a", "set b", "set c"},
+			evicted: []string{"a"},
+			kept:    []string{"b", "c"},
+		},
+		{
+			name:    "read entries are kept",
+			max:     2,
+			steps:   []string{"set a", "set b", "get a", "set c"},
+			evicted: []string{"b"},
+			kept:    []string{"a", "c"},
+		},
+		{
+			name:    "updated entries are kept",
+			max:     2,
+			steps:   []string{"set a", "set b", "set a", "set c"},
+			evicted: []string{"b"},
+			kept:    []string{"a", "c"},
+		},
+		{
+			name:    "several evictions",
+			max:     1,
+			steps:   []string{"set a", "set b", "set c"},
+			evicted: []string{"a", "b"},
+			kept:    []string{"c"},
+		},
+	}
+	for _, test := range tests {
+		cacher := createTestCacher(t, MemoryOptions{Max: test.max})
+		for _, step := range test.steps {
+			operation, key := step[:3], step[4:]
+			if operation == "set" {
+				cacher.Set(key, payload.New(key), 0)
+			} else {
+				cacher.Get(key)
+			}
+		}
+		for _, key := range test.evicted {
+			if _, found := cacher.Get(key); found {
+				t.Errorf("%s: expected %s to be evicted", test.name, key)
+			}
+		}
+		for _, key := range test.kept {
+			if _, found := cacher.Get(key); !found {
+				t.Errorf("%s: expected %s to be kept", test.name, key)
+			}
+		}
+	}
+}
+
+func TestTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		options MemoryOptions
+		ttl     time.Duration
+		wait    time.Duration
+		found   bool
+	}{
+		{"no ttl", MemoryOptions{}, 0, 30 * time.Millisecond, true},
+		{"default ttl", MemoryOptions{TTL: 10 * time.Millisecond}, 0, 30 * time.Millisecond, false},
+		{"entry ttl", MemoryOptions{}, 10 * time.Millisecond, 30 * time.Millisecond, false},
+		{"entry ttl over default", MemoryOptions{TTL: 10 * time.Millisecond}, time.Minute, 30 * time.Millisecond, true},
+		{"not expired yet", MemoryOptions{}, time.Minute, 0, true},
+	}
+	for _, test := range tests {
+		cacher := createTestCacher(t, test.options)
+		cacher.Set("key", payload.New("value"), test.ttl)
+		time.Sleep(test.wait)
+		if _, found := cacher.Get("key"); found != test.found {
+			t.Errorf("%s: expected found %t, got %t", test.name, test.found, found)
+		}
+	}
+}
+
+func TestGetWithTTL(t *testing.T) {
+	cacher := createTestCacher(t, MemoryOptions{})
+	cacher.Set("key", payload.New("value"), time.Minute)
+	_, ttl, found := cacher.GetWithTTL("key")
+	if !found || ttl <= 50*time.Second || ttl > time.Minute {
+		t.Fatalf("expected a ttl close to 1m, got %s found: %t", ttl, found)
+	}
+	cacher.Set("forever", payload.New("value"), 0)
+	if _, ttl, _ := cacher.GetWithTTL("forever"); ttl != 0 {
+		t.Fatalf("expected no ttl, got %s", ttl)
+	}
+}
+
+func TestCheckIntervalRemovesExpiredEntries(t *testing.T) {
+	cacher := createTestCacher(t, MemoryOptions{TTL: 10 * time.Millisecond, CheckInterval: 10 * time.Millisecond})
+	cacher.Set("key", payload.New("value"), 0)
+	time.Sleep(50 * time.Millisecond)
+	cacher.mutex.Lock()
+	defer cacher.mutex.Unlock()
+	if len(cacher.entries) != 0 || cacher.lru.Len() != 0 {
+		t.Fatalf("expected the expired entry to be removed, got %d entries", len(cacher.entries))
+	}
+}
+
+func TestClean(t *testing.T) {
+	keys := []string{"users.get:1", "users.get:2", "users.list:all", "posts.get:1"}
+	tests := []struct {
+		pattern string
+		removed []string
+	}{
+		{"", keys},
+		{"*", keys},
+		{"**", keys},
+		{"users.*", []string{"users.get:1", "users.get:2", "users.list:all"}},
+		{"users.get:*", []string{"users.get:1", "users.get:2"}},
+		{"*:1", []string{"users.get:1", "posts.get:1"}},
+		{"posts.get:1", []string{"posts.get:1"}},
+		{"comments.*", []string{}},
+	}
+	for _, test := range tests {
+		cacher := createTestCacher(t, MemoryOptions{})
+		for _, key := range keys {
+			cacher.Set(key, payload.New(key), 0)
+		}
+		cacher.Clean(test.pattern)
+		removed := map[string]bool{}
+		for _, key := range test.removed {
+			removed[key] = true
+		}
+		for _, key := range keys {
+			if _, found := cacher.Get(key); found == removed[key] {
+				t.Errorf("pattern %q: expected %s removed: %t", test.pattern, key, removed[key])
+			}
+		}
+	}
+}
+
+func TestDel(t *testing.T) {
+	cacher := createTestCacher(t, MemoryOptions{})
+	cacher.Set("a", payload.New(1), 0)
+	cacher.Set("b", payload.New(2), 0)
+	cacher.Del("a")
+	cacher.Del("missing")
+	if _, found := cacher.Get("a"); found {
+		t.Fatal("expected the key to be deleted")
+	}
+	if _, found := cacher.Get("b"); !found {
+		t.Fatal("expected the other keys to be kept")
+	}
+}
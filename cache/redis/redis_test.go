@@ -0,0 +1,106 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Bendomey/nucleo-go/payload"
+	"github.com/alicebob/miniredis/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+func createTestCacher(t *testing.T, options RedisOptions) (*RedisCacher, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	options.URL = "redis://" + server.Addr()
+	options.Logger = log.WithField("cacher", "redis-test")
+	cacher := CreateRedisCacher(options).(*RedisCacher)
+	t.Cleanup(cacher.Close)
+	return cacher, server
+}
+
+func TestSetGetWithoutBrokerSerializer(t *testing.T) {
+	cacher, server := createTestCacher(t, RedisOptions{})
+
+	cacher.Set("users.get:1", payload.New(map[string]interface{}{"name": "John"}), 0)
+	if !server.Exists("MOL-users.get:1") {
+		t.Fatal("expected the key to be stored with the prefix")
+	}
+	value, found := cacher.Get("users.get:1")
+	if !found {
+		t.Fatal("expected a cache hit")
+	}
+	if name := value.Get("name").String(); name != "John" {
+		t.Fatalf("expected name John, got %q", name)
+	}
+	if _, found := cacher.Get("users.get:2"); found {
+		t.Fatal("expected a cache miss")
+	}
+}
+
+func TestTTL(t *testing.T) {
+	cacher, server := createTestCacher(t, RedisOptions{TTL: 10 * time.Second})
+
+	cacher.Set("a", payload.New("default"), 0)
+	cacher.Set("b", payload.New("custom"), time.Minute)
+	if ttl := server.TTL("MOL-a"); ttl != 10*time.Second {
+		t.Fatalf("expected the default ttl, got %s", ttl)
+	}
+	_, ttl, found := cacher.GetWithTTL("b")
+	if !found || ttl <= 50*time.Second || ttl > time.Minute {
+		t.Fatalf("expected a ttl close to 1m, got %s found: %t", ttl, found)
+	}
+
+	server.FastForward(11 * time.Second)
+	if _, found := cacher.Get("a"); found {
+		t.Fatal("expected the entry to be expired")
+	}
+}
+
+func TestDelAndClean(t *testing.T) {
+	cacher, _ := createTestCacher(t, RedisOptions{ScanCount: 1})
+
+	cacher.Set("users.get:1", payload.New(1), 0)
+	cacher.Set("users.get:2", payload.New(2), 0)
+	cacher.Set("posts.get:1", payload.New(3), 0)
+
+	cacher.Del("users.get:1")
+	if _, found := cacher.Get("users.get:1"); found {
+		t.Fatal("expected the key to be deleted")
+	}
+	cacher.Clean("users.*")
+	if _, found := cacher.Get("users.get:2"); found {
+		t.Fatal("expected the key to be cleaned")
+	}
+	if _, found := cacher.Get("posts.get:1"); !found {
+		t.Fatal("expected keys not matching the pattern to be kept")
+	}
+	cacher.Clean("")
+	if _, found := cacher.Get("posts.get:1"); found {
+		t.Fatal("expected all keys to be cleaned")
+	}
+}
+
+func TestLock(t *testing.T) {
+	cacher, server := createTestCacher(t, RedisOptions{})
+
+	unlock, acquired := cacher.Lock("key", 0)
+	if !acquired {
+		t.Fatal("expected the lock to be acquired")
+	}
+	if _, acquired := cacher.Lock("key", 0); acquired {
+		t.Fatal("expected the lock to be held")
+	}
+	unlock()
+	if server.Exists("MOL-key-lock") {
+		t.Fatal("expected the lock to be released")
+	}
+
+	unlock, _ = cacher.Lock("key", 0)
+	// the lease expired and was taken by another caller, releasing it must not delete the new lease
+	server.Del("MOL-key-lock")
+	server.Set("MOL-key-lock", "other")
+	unlock()
+	if value, _ := server.Get("MOL-key-lock"); value != "other" {
+		t.Fatal("expected the lock of the other caller to be kept")
+	}
+}
@@ -0,0 +1,269 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	bus "github.com/Bendomey/nucleo-go/emitter"
+	"github.com/Bendomey/nucleo-go/errors"
+	"github.com/Bendomey/nucleo-go/middleware"
+	log "github.com/sirupsen/logrus"
+)
+
+type State string
+
+const (
+	StateClosed   State = "closed"
+	StateOpen     State = "open"
+	StateHalfOpen State = "half-open"
+)
+
+// windowBuckets is the number of buckets of the rolling window, the oldest bucket is dropped
+// each WindowTime / windowBuckets.
+const windowBuckets = 10
+
+// bucket counts the calls started in a slice of the rolling window.
+type bucket struct {
+	start    time.Time
+	count    int
+	failures int
+}
+
+// endpoint keeps the circuit state of one action in one node.
+type endpoint struct {
+	action     string
+	nodeID     string
+	state      State
+	buckets    []bucket
+	openedAt   time.Time
+	probing    bool
+	probeStart time.Time
+}
+
+// CircuitBreaker tracks the failure rate of remote action calls per action and node.
+type CircuitBreaker struct {
+	options   nucleo.CircuitBreaker
+	bus       *bus.Emitter
+	logger    *log.Entry
+	endpoints map[string]*endpoint
+	mutex     *sync.Mutex
+}
+
+func New(localBus *bus.Emitter, logger *log.Entry) *CircuitBreaker {
+	circuitBreaker := &CircuitBreaker{
+		options:   nucleo.DefaultConfig.CircuitBreaker,
+		bus:       localBus,
+		logger:    logger,
+		endpoints: make(map[string]*endpoint),
+		mutex:     &sync.Mutex{},
+	}
+	localBus.On("$node.disconnected", func(args ...interface{}) {
+		circuitBreaker.removeNode(args[0].(string))
+	})
+	return circuitBreaker
+}
+
+func key(action, nodeID string) string {
+	return nodeID + ":" + action
+}
+
+func (circuitBreaker *CircuitBreaker) removeNode(nodeID string) {
+	circuitBreaker.mutex.Lock()
+	defer circuitBreaker.mutex.Unlock()
+	for name, item := range circuitBreaker.endpoints {
+		if item.nodeID == nodeID {
+			delete(circuitBreaker.endpoints, name)
+		}
+	}
+}
+
+// IsAvailable check if the action in the node can be called. Nodes with open circuits are not available
+// until the half open time has passed.
+func (circuitBreaker *CircuitBreaker) IsAvailable(action, nodeID string) bool {
+	if !circuitBreaker.options.Enabled {
+		return true
+	}
+	circuitBreaker.mutex.Lock()
+	defer circuitBreaker.mutex.Unlock()
+	item, exists := circuitBreaker.endpoints[key(action, nodeID)]
+	if !exists {
+		return true
+	}
+	return circuitBreaker.available(item, time.Now())
+}
+
+func (circuitBreaker *CircuitBreaker) available(item *endpoint, now time.Time) bool {
+	switch item.state {
+	case StateOpen:
+		return now.Sub(item.openedAt) >= circuitBreaker.options.HalfOpenTime
+	case StateHalfOpen:
+		// a probe that never finished (e.g. rejected by another middleware) does not block the endpoint forever
+		return !item.probing || now.Sub(item.probeStart) >= circuitBreaker.options.HalfOpenTime
+	}
+	return true
+}
+
+// acquire is called before each remote call. When the circuit is open and the half open time
+// has passed the circuit is half opened and this call is used to test the endpoint.
+func (circuitBreaker *CircuitBreaker) acquire(action, nodeID string) bool {
+	circuitBreaker.mutex.Lock()
+	defer circuitBreaker.mutex.Unlock()
+	item, exists := circuitBreaker.endpoints[key(action, nodeID)]
+	if !exists || item.state == StateClosed {
+		return true
+	}
+	now := time.Now()
+	if !circuitBreaker.available(item, now) {
+		return false
+	}
+	if item.state == StateOpen {
+		item.state = StateHalfOpen
+		circuitBreaker.emit("$circuit-breaker.half-opened", item)
+	}
+	item.probing = true
+	item.probeStart = now
+	return true
+}
+
+// record the result of a remote call and update the circuit state.
+func (circuitBreaker *CircuitBreaker) record(action, nodeID string, err error) {
+	failure := err != nil && circuitBreaker.isFailure(err)
+
+	circuitBreaker.mutex.Lock()
+	defer circuitBreaker.mutex.Unlock()
+	name := key(action, nodeID)
+	item, exists := circuitBreaker.endpoints[name]
+	now := time.Now()
+	if !exists {
+		item = &endpoint{action: action, nodeID: nodeID, state: StateClosed}
+		circuitBreaker.endpoints[name] = item
+	}
+
+	switch item.state {
+	case StateHalfOpen:
+		item.probing = false
+		if failure {
+			circuitBreaker.open(item, now)
+		} else {
+			circuitBreaker.close(item)
+		}
+	case StateClosed:
+		item.add(now, circuitBreaker.options.WindowTime, failure)
+		count, _ := item.totals()
+		if count >= circuitBreaker.options.MinRequestCount && item.failureRate() >= circuitBreaker.options.Threshold {
+			circuitBreaker.open(item, now)
+		}
+	}
+}
+
+// add count the call in the current bucket, after dropping the buckets that are out of the window.
+func (item *endpoint) add(now time.Time, window time.Duration, failure bool) {
+	expired := 0
+	for expired < len(item.buckets) && now.Sub(item.buckets[expired].start) >= window {
+		expired++
+	}
+	item.buckets = item.buckets[expired:]
+	last := len(item.buckets) - 1
+	if last < 0 || now.Sub(item.buckets[last].start) >= window/windowBuckets {
+		item.buckets = append(item.buckets, bucket{start: now})
+		last++
+	}
+	item.buckets[last].count++
+	if failure {
+		item.buckets[last].failures++
+	}
+}
+
+// totals return the number of calls and failures in the window.
+func (item *endpoint) totals() (int, int) {
+	count, failures := 0, 0
+	for _, bucket := range item.buckets {
+		count += bucket.count
+		failures += bucket.failures
+	}
+	return count, failures
+}
+
+func (circuitBreaker *CircuitBreaker) isFailure(err error) bool {
+	if circuitBreaker.options.Check != nil {
+		return circuitBreaker.options.Check(err)
+	}
+	return true
+}
+
+func (item *endpoint) failureRate() float64 {
+	count, failures := item.totals()
+	if count == 0 {
+		return 0
+	}
+	return float64(failures) / float64(count)
+}
+
+func (circuitBreaker *CircuitBreaker) open(item *endpoint, now time.Time) {
+	item.state = StateOpen
+	item.openedAt = now
+	count, failures := item.totals()
+	circuitBreaker.logger.Warnln("Circuit breaker opened - action: ", item.action, " nodeID: ", item.nodeID, " failures: ", failures, "/", count)
+	circuitBreaker.emit("$circuit-breaker.opened", item)
+}
+
+func (circuitBreaker *CircuitBreaker) close(item *endpoint) {
+	item.state = StateClosed
+	item.buckets = nil
+	circuitBreaker.logger.Infoln("Circuit breaker closed - action: ", item.action, " nodeID: ", item.nodeID)
+	circuitBreaker.emit("$circuit-breaker.closed", item)
+}
+
+func (circuitBreaker *CircuitBreaker) emit(event string, item *endpoint) {
+	count, failures := item.totals()
+	circuitBreaker.bus.EmitAsync(event, []interface{}{map[string]interface{}{
+		"action":   item.action,
+		"nodeID":   item.nodeID,
+		"count":    count,
+		"failures": failures,
+		"rate":     item.failureRate(),
+	}})
+}
+
+// Middlewares create the circuit breaker middleware.
+func (circuitBreaker *CircuitBreaker) Middlewares() nucleo.Middlewares {
+	return map[string]nucleo.MiddlewareHandler{
+		// store the broker config
+		"Config": func(params interface{}, next func(...interface{})) {
+			circuitBreaker.options = params.(nucleo.Config).CircuitBreaker
+			next()
+		},
+		"beforeRemoteAction": func(params interface{}, next func(...interface{})) {
+			// a previous middleware, e.g. the validator, already replaced the context with an error
+			context, isContext := params.(nucleo.BrokerContext)
+			if !isContext {
+				next(params)
+				return
+			}
+			if !circuitBreaker.options.Enabled || circuitBreaker.acquire(context.ActionName(), context.TargetNodeID()) {
+				next()
+				return
+			}
+			message := fmt.Sprint("Circuit breaker is open - action: ", context.ActionName(), " nodeID: ", context.TargetNodeID())
+			err := errors.NewNucleoRetryableError(errors.NewNucleoRetryableErrorInput{
+				Message: &message,
+				Type:    "CIRCUIT_BREAKER_OPEN",
+				Data:    map[string]interface{}{"action": context.ActionName(), "nodeID": context.TargetNodeID()},
+			})
+			next(&err)
+		},
+		"afterRemoteAction": func(params interface{}, next func(...interface{})) {
+			if circuitBreaker.options.Enabled {
+				payload := params.(middleware.AfterActionParams)
+				var err error
+				if payload.Result.IsError() {
+					err = payload.Result.Error()
+				}
+				circuitBreaker.record(payload.BrokerContext.ActionName(), payload.BrokerContext.TargetNodeID(), err)
+			}
+			next()
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package circuitbreaker_test
+
+import (
+	"testing"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/circuitbreaker"
+	"github.com/Bendomey/nucleo-go/context"
+	bus "github.com/Bendomey/nucleo-go/emitter"
+	"github.com/Bendomey/nucleo-go/errors"
+	"github.com/Bendomey/nucleo-go/middleware"
+	"github.com/Bendomey/nucleo-go/payload"
+	"github.com/Bendomey/nucleo-go/registry"
+	"github.com/Bendomey/nucleo-go/validators"
+	log "github.com/sirupsen/logrus"
+)
+
+// the broker registers the validator before the circuit breaker, the remote calls run both middlewares in that order.
+func TestValidationErrorWithCircuitBreaker(t *testing.T) {
+	logger := log.WithField("middleware", "circuit-breaker-test")
+	config := nucleo.DefaultConfig
+	config.CircuitBreaker.Enabled = true
+	dispatch := middleware.Dispatcher(logger)
+	dispatch.Add(validators.Resolve(config).Middlewares())
+	dispatch.Add(circuitbreaker.New(bus.Construct(), logger).Middlewares())
+	dispatch.CallHandlers("Config", config)
+
+	delegates := &nucleo.BrokerDelegates{
+		Config:    config,
+		LocalNode: func() nucleo.Node { return registry.CreateNode("client", true, logger) },
+		Logger:    func(name string, value string) *log.Entry { return logger.WithField(name, value) },
+	}
+	callContext := context.BrokerContext(delegates).ChildActionContext("math.add", payload.New(map[string]interface{}{}))
+	callContext.SetPayloadSchema(map[string]interface{}{"a": "required,number"})
+	callContext.SetTargetNodeID("worker")
+
+	result := dispatch.CallHandlers("beforeRemoteAction", callContext)
+	if validationError, isValidation := result.(errors.NucleoValidationError); !isValidation || validationError.Type != "VALIDATION_ERROR" {
+		t.Fatalf("expected a VALIDATION_ERROR, got %v", result)
+	}
+
+	validContext := context.BrokerContext(delegates).ChildActionContext("math.add", payload.New(map[string]interface{}{"a": 1}))
+	validContext.SetPayloadSchema(map[string]interface{}{"a": "required,number"})
+	validContext.SetTargetNodeID("worker")
+	if result := dispatch.CallHandlers("beforeRemoteAction", validContext); result != validContext {
+		t.Fatalf("expected the context of a valid call, got %v", result)
+	}
+}
@@ -3,6 +3,7 @@ package context
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Bendomey/nucleo-go"
 	"github.com/Bendomey/nucleo-go/payload"
@@ -24,7 +25,7 @@ type Context struct {
 	paramsSchema map[string]interface{}
 	params       nucleo.Payload
 	meta         nucleo.Payload
-	timeout      int
+	deadline     time.Time
 	level        int
 	caller       string
 }
@@ -112,14 +113,26 @@ func (context *Context) ChildActionContext(actionName string, params nucleo.Payl
 		meta:       meta,
 		parentID:   parentContext.id,
 		caller:     caller,
+		deadline:   parentContext.deadline,
 	}
 	return &actionContext
 }
 
+// Copy create a copy of the context with a new ID. The level, parent, caller, request ID and deadline are kept,
+// so the copy is the same call and not a nested one.
+func (context *Context) Copy() nucleo.BrokerContext {
+	copied := *context
+	copied.id = utils.RandomString(12)
+	if copied.requestID == "" {
+		copied.requestID = context.id
+	}
+	return &copied
+}
+
 // ActionContext create an action context for remote call.
 func ActionContext(broker *nucleo.BrokerDelegates, values map[string]interface{}) nucleo.BrokerContext {
 	var level int
-	var timeout int
+	var deadline time.Time
 	var meta nucleo.Payload
 
 	sourceNodeID := values["sender"].(string)
@@ -142,8 +155,9 @@ func ActionContext(broker *nucleo.BrokerDelegates, values map[string]interface{}
 	// }
 	params := payload.New(values["params"])
 
-	if values["timeout"] != nil {
-		timeout = values["timeout"].(int)
+	// timeout is the remaining time budget (in milliseconds) of the caller
+	if values["timeout"] != nil && values["timeout"].(int) > 0 {
+		deadline = time.Now().Add(time.Duration(values["timeout"].(int)) * time.Millisecond)
 	}
 	if values["meta"] != nil {
 		meta = payload.New(values["meta"])
@@ -160,7 +174,7 @@ func ActionContext(broker *nucleo.BrokerDelegates, values map[string]interface{}
 		parentID:     parentID,
 		params:       params,
 		meta:         meta,
-		timeout:      timeout,
+		deadline:     deadline,
 		level:        level,
 	}
 
@@ -236,7 +250,7 @@ func (context *Context) AsMap() map[string]interface{} {
 
 	if context.actionName != "" {
 		mapResult["action"] = context.actionName
-		mapResult["timeout"] = context.timeout
+		mapResult["timeout"] = context.remainingTimeout()
 		mapResult["params"] = context.params.Value()
 	}
 	if context.eventName != "" {
@@ -247,9 +261,8 @@ func (context *Context) AsMap() map[string]interface{} {
 		mapResult["level"] = context.level
 	}
 
-	//streaming not supported yet
+	// stream params are sent by the transit in chunks, each with its seq
 	mapResult["stream"] = false
-	//mapResult["seq"] = 0 // for stream payloads
 
 	return mapResult
 }
@@ -278,6 +291,16 @@ func (context *Context) Broadcast(eventName string, params interface{}, groups .
 	context.broker.BroadcastEvent(newContext)
 }
 
+// CacheClean : remove the cached entries matching the pattern on all nodes
+func (context *Context) CacheClean(pattern string) {
+	context.Broadcast("$cacher.clean", map[string]interface{}{"pattern": pattern})
+}
+
+// CacheDel : remove the cached keys on all nodes
+func (context *Context) CacheDel(keys ...string) {
+	context.Broadcast("$cacher.del", map[string]interface{}{"keys": keys})
+}
+
 func (context *Context) WaitFor(services ...string) error {
 	return context.broker.WaitFor(services...)
 }
@@ -323,6 +346,33 @@ func (context *Context) SourceNodeID() string {
 	return context.sourceNodeID
 }
 
+// Level return the depth of the context in the call chain.
+func (context *Context) Level() int {
+	return context.level
+}
+
+// Deadline return the time when the action call times out, zero when there is no deadline.
+func (context *Context) Deadline() time.Time {
+	return context.deadline
+}
+
+func (context *Context) SetDeadline(deadline time.Time) {
+	context.deadline = deadline
+}
+
+// remainingTimeout return the time budget left in milliseconds, sent to remote nodes so nested calls inherit the deadline.
+func (context *Context) remainingTimeout() int {
+	if context.deadline.IsZero() {
+		return 0
+	}
+	remaining := int(time.Until(context.deadline) / time.Millisecond)
+	if remaining < 1 {
+		// 0 means no timeout, so an expired deadline is sent as the smallest budget
+		return 1
+	}
+	return remaining
+}
+
 func (context *Context) ID() string {
 	return context.id
 }
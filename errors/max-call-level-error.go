@@ -0,0 +1,39 @@
+package errors
+
+import "fmt"
+
+// MaxCallLevelError is returned when the call chain reaches Config.MaxCallLevel, e.g. services calling each other in a loop.
+type MaxCallLevelError struct {
+	NucleoError
+}
+
+type NewMaxCallLevelErrorInput struct {
+	Action       string
+	NodeID       string
+	Level        int
+	MaxCallLevel int
+}
+
+func NewMaxCallLevelError(input NewMaxCallLevelErrorInput) MaxCallLevelError {
+	code := 500
+	message := fmt.Sprintf("Request level has reached the limit (%d) when call '%s' action on '%s' node.", input.MaxCallLevel, input.Action, input.NodeID)
+	nucleoError := NewNucleoError(NewNucleoErrorInput{
+		Message: &message,
+		Code:    &code,
+		Type:    "MAX_CALL_LEVEL",
+		Data: map[string]interface{}{
+			"action":       input.Action,
+			"nodeID":       input.NodeID,
+			"level":        input.Level,
+			"maxCallLevel": input.MaxCallLevel,
+		},
+	})
+
+	return MaxCallLevelError{
+		NucleoError: nucleoError,
+	}
+}
+
+func (e *MaxCallLevelError) Error() string {
+	return e.Message
+}
@@ -38,3 +38,13 @@ func NewNucleoError(input NewNucleoErrorInput) NucleoError {
 func (e *NucleoError) Error() string {
 	return e.Message
 }
+
+func (e *NucleoError) IsRetryable() bool {
+	return e.Retryable
+}
+
+// IsRetryable check if the error can be retried, e.g. a NucleoRetryableError.
+func IsRetryable(err error) bool {
+	retryable, ok := err.(interface{ IsRetryable() bool })
+	return ok && retryable.IsRetryable()
+}
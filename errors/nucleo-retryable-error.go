@@ -5,19 +5,23 @@ type NucleoRetryableError struct {
 }
 
 type NewNucleoRetryableErrorInput struct {
-	Code *int
-	Type string
-	Data interface{}
+	Message *string
+	Code    *int
+	Type    string
+	Data    interface{}
 }
 
 func NewNucleoRetryableError(input NewNucleoRetryableErrorInput) NucleoRetryableError {
+	nucleoError := NewNucleoError(NewNucleoErrorInput{
+		Message: input.Message,
+		Code:    input.Code,
+		Type:    input.Type,
+		Data:    input.Data,
+	})
+	nucleoError.Retryable = true
 
 	return NucleoRetryableError{
-		NucleoError: NucleoError{
-			Type:      input.Type,
-			Data:      input.Data,
-			Retryable: true,
-		},
+		NucleoError: nucleoError,
 	}
 }
 
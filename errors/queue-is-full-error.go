@@ -0,0 +1,39 @@
+package errors
+
+import "fmt"
+
+// QueueIsFullError is returned when the bulkhead of an action has no free slot and its queue is full.
+type QueueIsFullError struct {
+	NucleoRetryableError
+}
+
+type NewQueueIsFullErrorInput struct {
+	Action       string
+	NodeID       string
+	Concurrency  int
+	MaxQueueSize int
+}
+
+func NewQueueIsFullError(input NewQueueIsFullErrorInput) QueueIsFullError {
+	code := 429
+	message := fmt.Sprintf("Queue is full. Request '%s' action on '%s' node is rejected.", input.Action, input.NodeID)
+	retryableError := NewNucleoRetryableError(NewNucleoRetryableErrorInput{
+		Message: &message,
+		Code:    &code,
+		Type:    "QUEUE_FULL",
+		Data: map[string]interface{}{
+			"action":       input.Action,
+			"nodeID":       input.NodeID,
+			"concurrency":  input.Concurrency,
+			"maxQueueSize": input.MaxQueueSize,
+		},
+	})
+
+	return QueueIsFullError{
+		NucleoRetryableError: retryableError,
+	}
+}
+
+func (e *QueueIsFullError) Error() string {
+	return e.Message
+}
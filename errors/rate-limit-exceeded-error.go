@@ -0,0 +1,43 @@
+package errors
+
+import (
+	"fmt"
+	"time"
+)
+
+// RateLimitExceededError is returned when a call is rejected by a rate limit.
+// Data["retryAfter"] is the time (in milliseconds) until the next call is allowed.
+type RateLimitExceededError struct {
+	NucleoRetryableError
+}
+
+type NewRateLimitExceededErrorInput struct {
+	Action     string
+	NodeID     string
+	Limit      string
+	RetryAfter time.Duration
+}
+
+func NewRateLimitExceededError(input NewRateLimitExceededErrorInput) RateLimitExceededError {
+	code := 429
+	message := fmt.Sprintf("Rate limit exceeded when call '%s' action on '%s' node. Retry after %s.", input.Action, input.NodeID, input.RetryAfter.Round(time.Millisecond))
+	retryableError := NewNucleoRetryableError(NewNucleoRetryableErrorInput{
+		Message: &message,
+		Code:    &code,
+		Type:    "RATE_LIMIT_EXCEEDED",
+		Data: map[string]interface{}{
+			"action":     input.Action,
+			"nodeID":     input.NodeID,
+			"limit":      input.Limit,
+			"retryAfter": input.RetryAfter.Milliseconds(),
+		},
+	})
+
+	return RateLimitExceededError{
+		NucleoRetryableError: retryableError,
+	}
+}
+
+func (e *RateLimitExceededError) Error() string {
+	return e.Message
+}
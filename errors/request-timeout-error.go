@@ -0,0 +1,35 @@
+package errors
+
+import "fmt"
+
+// RequestTimeoutError is returned when an action call does not finish before its deadline.
+type RequestTimeoutError struct {
+	NucleoRetryableError
+}
+
+type NewRequestTimeoutErrorInput struct {
+	Action string
+	NodeID string
+}
+
+func NewRequestTimeoutError(input NewRequestTimeoutErrorInput) RequestTimeoutError {
+	code := 504
+	message := fmt.Sprintf("Request is timed out when call '%s' action on '%s' node.", input.Action, input.NodeID)
+	retryableError := NewNucleoRetryableError(NewNucleoRetryableErrorInput{
+		Message: &message,
+		Code:    &code,
+		Type:    "REQUEST_TIMEOUT",
+		Data: map[string]interface{}{
+			"action": input.Action,
+			"nodeID": input.NodeID,
+		},
+	})
+
+	return RequestTimeoutError{
+		NucleoRetryableError: retryableError,
+	}
+}
+
+func (e *RequestTimeoutError) Error() string {
+	return e.Message
+}
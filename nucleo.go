@@ -114,6 +114,21 @@ type Mixin struct {
 type TransporterFactoryFunc func() interface{}
 type StrategyFactoryFunc func() interface{}
 
+// Cacher is the part of the cache.Cache contract used by the config, it is declared here so the config
+// does not import the cache package. Config.Cacher must be a cache.Cache, e.g. memory.CreateMemoryCacher().
+type Cacher interface {
+	// Get return the value stored for the key and true, or false when the key is not cached (or expired).
+	Get(key string) (Payload, bool)
+	// Set store the value for the given ttl. When ttl is zero the cacher default ttl is used.
+	Set(key string, value Payload, ttl time.Duration)
+	// Del remove the key from the cache.
+	Del(key string)
+	// Clean remove all keys matching the pattern (e.g. "users.*"). An empty pattern removes everything.
+	Clean(pattern string)
+	// Close release any resources (background routines, connections) used by the cacher.
+	Close()
+}
+
 type ValidatorType string
 
 const (
@@ -143,6 +158,9 @@ type StrategyType string
 const (
 	StrategyRoundRobin StrategyType = "RoundRobin"
 	StrategyRandom     StrategyType = "Random"
+	StrategyCPUUsage   StrategyType = "CPUUsage"
+	StrategyLatency    StrategyType = "Latency"
+	StrategyShard      StrategyType = "Shard"
 )
 
 type SerializerType string
@@ -161,6 +179,7 @@ type Config struct {
 	TransporterFactory         TransporterFactoryFunc
 	Strategy                   StrategyType
 	StrategyFactory            StrategyFactoryFunc
+	Cacher                     Cacher // must implement cache.Cache, e.g. memory.CreateMemoryCacher()
 	HeartbeatFrequency         time.Duration
 	HeartbeatTimeout           time.Duration
 	OfflineCheckFrequency      time.Duration
@@ -169,9 +188,23 @@ type Config struct {
 	WaitForDependenciesTimeout time.Duration
 	Middlewares                []Middlewares
 	Namespace                  string
-	RequestTimeout             time.Duration
+	RequestTimeout             time.Duration // remote calls only, local calls time out with Options.Timeout or Action.Settings["timeout"]
 	MCallTimeout               time.Duration
 	RetryPolicy                RetryPolicy
+	CircuitBreaker             CircuitBreaker
+	Bulkhead                   Bulkhead
+	RateLimit                  RateLimit
+	Latency                    Latency
+	Shard                      Shard
+	// TransporterOptions are the options of the transporter resolved from the Transporter url,
+	// e.g. {"prefetch": 10} for amqp://. Options in the url query take precedence.
+	TransporterOptions map[string]interface{}
+	// PreferLocal calls the local endpoint of an action or event when one exists, instead of load balancing
+	// between all nodes. Nil means true. It does not apply to the Shard strategy, which needs a stable node per key.
+	PreferLocal *bool
+	// Metadata of the local node, e.g. region, role or build SHA. It is published to the other nodes
+	// in the INFO packet and can be used to select nodes with Options.NodeSelector.
+	Metadata                   map[string]interface{}
 	MaxCallLevel               int
 	Metrics                    bool
 	MetricsRate                float32
@@ -220,13 +253,48 @@ var DefaultConfig = Config{
 	Stopped:                    func() {},
 	MaxCallLevel:               100,
 	RetryPolicy: RetryPolicy{
+		Enabled:  false,
+		Retries:  5,
+		Delay:    100,
+		MaxDelay: 1000,
+		Factor:   2,
+	},
+	CircuitBreaker: CircuitBreaker{
+		Enabled:         false,
+		Threshold:       0.5,
+		MinRequestCount: 20,
+		WindowTime:      60 * time.Second,
+		HalfOpenTime:    10 * time.Second,
+	},
+	Bulkhead: Bulkhead{
+		Enabled:      false,
+		Concurrency:  3,
+		MaxQueueSize: 10,
+	},
+	RateLimit: RateLimit{
 		Enabled: false,
+		Rate:    100,
+		Burst:   100,
+		Wait:    false,
+		MaxWait: 0,
+	},
+	Latency: Latency{
+		Enabled:      false,
+		PingInterval: 10 * time.Second,
+		Alpha:        0.3,
+	},
+	Shard: Shard{
+		Key:          "",
+		VirtualNodes: 10,
 	},
 	RequestTimeout:            3 * time.Second,
 	MCallTimeout:              5 * time.Second,
 	WaitForNeighboursInterval: 200 * time.Millisecond,
 }
 
+// RetryPolicy configure the retries of failed action calls.
+// Delay and MaxDelay are in milliseconds, the delay is multiplied by Factor on each retry.
+// Check decides which errors are retried, by default errors.IsRetryable.
 type RetryPolicy struct {
 	Enabled  bool
 	Retries  int
@@ -236,9 +304,73 @@ type RetryPolicy struct {
 	Check    func(error) bool
 }
 
+// CircuitBreaker configure the circuit breaker of remote action calls. The failure rate is tracked per action and node,
+// when it reaches Threshold (0-1) with at least MinRequestCount requests in the last WindowTime the circuit opens and the node
+// is not selected for that action. After HalfOpenTime a single request is let through to test the node.
+// Check decides which errors count as failures, by default all errors.
+type CircuitBreaker struct {
+	Enabled         bool
+	Threshold       float64
+	MinRequestCount int
+	WindowTime      time.Duration
+	HalfOpenTime    time.Duration
+	Check           func(error) bool
+}
+
+// Bulkhead limit the concurrent invocations of each local action. Calls over Concurrency wait in a queue until their deadline,
+// and fail with errors.QueueIsFullError when the queue already has MaxQueueSize calls.
+// Actions can override it with Action.Settings["bulkhead"].
+type Bulkhead struct {
+	Enabled      bool
+	Concurrency  int
+	MaxQueueSize int
+}
+
+// RateLimit configure a token bucket limiting the calls of local actions. Rate is the number of calls
+// allowed per second and Burst the size of the bucket. When Wait is true excess calls are delayed until
+// a token is available (up to MaxWait), otherwise they fail with errors.RateLimitExceededError.
+// Services and actions can have their own limit with Settings["rateLimit"].
+type RateLimit struct {
+	Enabled bool
+	Rate    float64
+	Burst   int
+	Wait    bool
+	MaxWait time.Duration
+}
+
+// Latency configure the latency collector, which pings the remote nodes every PingInterval and keeps an
+// exponentially weighted moving average of the round trip per node. Alpha is the weight of the new sample.
+// The collector is always enabled when Config.Strategy is StrategyLatency. Enable it when only some actions
+// use the latency strategy in their settings.
+type Latency struct {
+	Enabled      bool
+	PingInterval time.Duration
+	Alpha        float64
+}
+
+// Shard configure the shard strategy (StrategyShard). Key is the path of the shard key in the params
+// ("params.userID") or in the meta ("#meta.tenant") and VirtualNodes the number of points of each node in the hash ring.
+type Shard struct {
+	Key          string
+	VirtualNodes int
+}
+
 type Options struct {
 	Meta   Payload
 	NodeID string
+	// Retries overrides RetryPolicy.Retries for this call. A negative value disables the retries.
+	Retries int
+	// Timeout of each attempt of this call, overrides Action.Settings["timeout"] and Config.RequestTimeout.
+	// The deadline of the caller is never extended, nested calls get the smallest of both.
+	Timeout time.Duration
+	// FallbackResponse is returned when the call fails after all retries.
+	// It can be a static value or a FallbackFunc.
+	FallbackResponse interface{}
+	// HedgeDelay enables hedged requests: when a remote node does not answer within the delay the request is
+	// also sent to another node and the first response is used. Overrides Action.Settings["hedge"].
+	HedgeDelay time.Duration
+	// NodeSelector only allows nodes whose metadata contains all the labels, e.g. {"zone": "eu-1"}.
+	NodeSelector map[string]string
 }
 
 type Context interface {
@@ -247,10 +379,15 @@ type Context interface {
 	Call(actionName string, params interface{}, opts ...Options) chan Payload
 	Emit(eventName string, params interface{}, groups ...string)
 	Broadcast(eventName string, params interface{}, groups ...string)
+	CacheClean(pattern string)
+	CacheDel(keys ...string)
 	Logger() *log.Entry
 
 	Payload() Payload
 	Meta() Payload
+	// Deadline return the time when the call times out, zero when it has no deadline.
+	// The handler is not interrupted at the deadline, long running handlers can check it to stop early.
+	Deadline() time.Time
 }
 
 type BrokerContext interface {
@@ -259,6 +396,8 @@ type BrokerContext interface {
 
 	ChildActionContext(actionName string, params Payload, opts ...Options) BrokerContext
 	ChildEventContext(eventName string, params Payload, groups []string, broadcast bool) BrokerContext
+	// Copy create a copy of the context with a new ID, e.g. for another attempt of the same call.
+	Copy() BrokerContext
 
 	ActionName() string
 	EventName() string
@@ -274,6 +413,10 @@ type BrokerContext interface {
 
 	SetTargetNodeID(targetNodeID string)
 	TargetNodeID() string
+	SourceNodeID() string
+	Level() int
+	Deadline() time.Time
+	SetDeadline(deadline time.Time)
 
 	ID() string
 	RequestID() string
@@ -286,6 +429,7 @@ type BrokerContext interface {
 }
 
 type ActionHandler func(context Context, params Payload) interface{}
+type FallbackFunc func(context Context, err error) interface{}
 type EventHandler func(context Context, params Payload)
 type CreatedFunc func(ServiceSchema, *log.Entry)
 type LifecycleFunc func(BrokerContext, ServiceSchema)
@@ -303,6 +447,7 @@ type BrokerContextFunc func() BrokerContext
 type MiddlewareHandlerFunc func(name string, params interface{}) interface{}
 type PublishServicesFunc func(...interface{})
 type WaitForFunc func(...string) error
+type EndpointAvailableFunc func(actionName string, nodeID string) bool
 type MiddlewareHandler func(params interface{}, next func(...interface{}))
 
 type Middlewares map[string]MiddlewareHandler
@@ -324,6 +469,7 @@ type BrokerDelegates struct {
 	MiddlewareHandler  MiddlewareHandlerFunc
 	PublishServices    PublishServicesFunc
 	WaitFor            WaitForFunc
+	EndpointAvailable  EndpointAvailableFunc
 }
 
 type Node interface {
@@ -337,6 +483,11 @@ type Node interface {
 	Update(id string, info map[string]interface{}) bool
 
 	IncreaseSequence()
+	GetCPU() int64
+	UpdateCPU(cpu int64)
+	GetLatency() time.Duration
+	UpdateLatency(latency time.Duration)
+	GetMetadata() map[string]interface{}
 	HeartBeat(heartbeat map[string]interface{})
 	Publish(service map[string]interface{})
 }
@@ -0,0 +1,204 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/cache"
+	"github.com/Bendomey/nucleo-go/errors"
+	"github.com/Bendomey/nucleo-go/payload"
+)
+
+// SettingsResolver return the settings of the local action and of its service.
+type SettingsResolver func(actionName string) (actionSettings map[string]interface{}, serviceName string, serviceSettings map[string]interface{})
+
+// bucket is a token bucket refilled with rate tokens per second up to burst tokens.
+type bucket struct {
+	options nucleo.RateLimit
+	tokens  float64
+	last    time.Time
+	mutex   *sync.Mutex
+}
+
+func createBucket(options nucleo.RateLimit) *bucket {
+	if options.Burst < 1 {
+		options.Burst = int(math.Max(1, math.Ceil(options.Rate)))
+	}
+	return &bucket{options: options, tokens: float64(options.Burst), last: time.Now(), mutex: &sync.Mutex{}}
+}
+
+// take a token from the bucket. When the bucket is empty return the time until the next token is available.
+// With the Wait option the token is reserved when the wait is under MaxWait, so the caller only has to wait.
+func (bucket *bucket) take(now time.Time) (allowed bool, wait time.Duration) {
+	bucket.mutex.Lock()
+	defer bucket.mutex.Unlock()
+
+	elapsed := math.Max(0, now.Sub(bucket.last).Seconds())
+	if now.After(bucket.last) {
+		bucket.last = now
+	}
+	bucket.tokens = math.Min(float64(bucket.options.Burst), bucket.tokens+elapsed*bucket.options.Rate)
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	if bucket.options.Rate <= 0 {
+		return false, 0
+	}
+	wait = time.Duration((1 - bucket.tokens) / bucket.options.Rate * float64(time.Second))
+	if bucket.options.Wait && wait <= bucket.options.MaxWait {
+		bucket.tokens--
+		return true, wait
+	}
+	return false, wait
+}
+
+// refund give back a token taken by a call that was rejected by another bucket.
+func (bucket *bucket) refund() {
+	bucket.mutex.Lock()
+	defer bucket.mutex.Unlock()
+	bucket.tokens = math.Min(float64(bucket.options.Burst), bucket.tokens+1)
+}
+
+func (bucket *bucket) limit() string {
+	return fmt.Sprint(bucket.options.Rate, "/s")
+}
+
+// parseSettings merge the "rateLimit" entry of the settings with the base options.
+// The setting accepts true/false or a map with "rate", "burst", "wait" and "maxWait" (time.Duration or milliseconds).
+func parseSettings(base nucleo.RateLimit, settings map[string]interface{}) (nucleo.RateLimit, bool) {
+	if settings == nil || settings["rateLimit"] == nil {
+		return base, false
+	}
+	if enabled, isBool := settings["rateLimit"].(bool); isBool {
+		return base, enabled
+	}
+	values := payload.New(settings["rateLimit"])
+	if !values.IsMap() {
+		return base, false
+	}
+	options := base
+	if values.Get("enabled").Exists() && !values.Get("enabled").Bool() {
+		return options, false
+	}
+	if values.Get("rate").Exists() {
+		options.Rate = values.Get("rate").Float()
+		options.Burst = 0
+	}
+	if values.Get("burst").Exists() {
+		options.Burst = values.Get("burst").Int()
+	}
+	if values.Get("wait").Exists() {
+		options.Wait = values.Get("wait").Bool()
+	}
+	if values.Get("maxWait").Exists() {
+		options.MaxWait = cache.ToDuration(values.Get("maxWait").Value(), time.Millisecond)
+	}
+	return options, true
+}
+
+type rateLimiter struct {
+	options nucleo.RateLimit
+	resolve SettingsResolver
+	buckets sync.Map
+}
+
+// bucket return the bucket stored under the key, created with the options on first use.
+func (limiter *rateLimiter) bucket(key string, options nucleo.RateLimit) *bucket {
+	if value, exists := limiter.buckets.Load(key); exists {
+		return value.(*bucket)
+	}
+	value, _ := limiter.buckets.LoadOrStore(key, createBucket(options))
+	return value.(*bucket)
+}
+
+// bucketsFor return the global, service and action buckets that apply to the action.
+func (limiter *rateLimiter) bucketsFor(actionName string) []*bucket {
+	result := []*bucket{}
+	if limiter.options.Enabled && strings.Index(actionName, "$") != 0 {
+		result = append(result, limiter.bucket("*", limiter.options))
+	}
+	if limiter.resolve == nil {
+		return result
+	}
+	actionSettings, serviceName, serviceSettings := limiter.resolve(actionName)
+	if options, enabled := parseSettings(limiter.options, serviceSettings); enabled {
+		result = append(result, limiter.bucket("service:"+serviceName, options))
+	}
+	if options, enabled := parseSettings(limiter.options, actionSettings); enabled {
+		result = append(result, limiter.bucket("action:"+actionName, options))
+	}
+	return result
+}
+
+// delegatesContext is implemented by the contexts that give access to the broker, e.g. context.Context.
+type delegatesContext interface {
+	BrokerDelegates() *nucleo.BrokerDelegates
+}
+
+func localNodeID(brokerContext nucleo.BrokerContext) string {
+	if withDelegates, valid := brokerContext.(delegatesContext); valid && withDelegates.BrokerDelegates() != nil {
+		return withDelegates.BrokerDelegates().LocalNode().GetID()
+	}
+	return ""
+}
+
+// check take a token from all the buckets of the action. Return the error payload when a limit is exceeded,
+// the tokens already taken from the other buckets are then given back.
+func (limiter *rateLimiter) check(brokerContext nucleo.BrokerContext) interface{} {
+	var wait time.Duration
+	now := time.Now()
+	buckets := limiter.bucketsFor(brokerContext.ActionName())
+	for index, bucket := range buckets {
+		allowed, bucketWait := bucket.take(now)
+		if !allowed {
+			for _, taken := range buckets[:index] {
+				taken.refund()
+			}
+			err := errors.NewRateLimitExceededError(errors.NewRateLimitExceededErrorInput{
+				Action:     brokerContext.ActionName(),
+				NodeID:     localNodeID(brokerContext),
+				Limit:      bucket.limit(),
+				RetryAfter: bucketWait,
+			})
+			return &err
+		}
+		if bucketWait > wait {
+			wait = bucketWait
+		}
+	}
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+	return nil
+}
+
+// Middlewares create the rate limit middleware. The global limit is taken from Config.RateLimit and the
+// service and action limits from their settings, returned by the resolver.
+func Middlewares(resolve SettingsResolver) nucleo.Middlewares {
+	limiter := &rateLimiter{options: nucleo.DefaultConfig.RateLimit, resolve: resolve}
+	return map[string]nucleo.MiddlewareHandler{
+		// store the broker config
+		"Config": func(params interface{}, next func(...interface{})) {
+			limiter.options = params.(nucleo.Config).RateLimit
+			next()
+		},
+		"beforeLocalAction": func(params interface{}, next func(...interface{})) {
+			// a previous middleware, e.g. the validator, already replaced the context with an error
+			brokerContext, isContext := params.(nucleo.BrokerContext)
+			if !isContext {
+				next(params)
+				return
+			}
+			if err := limiter.check(brokerContext); err != nil {
+				next(err)
+				return
+			}
+			next()
+		},
+	}
+}
@@ -0,0 +1,38 @@
+package ratelimit_test
+
+import (
+	"testing"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/broker"
+	"github.com/Bendomey/nucleo-go/errors"
+)
+
+func TestValidationErrorWithRateLimit(t *testing.T) {
+	bkr := broker.New(&nucleo.Config{
+		LogLevel:  nucleo.LogLevelFatal,
+		RateLimit: nucleo.RateLimit{Enabled: true, Rate: 100},
+	})
+	bkr.PublishServices(nucleo.ServiceSchema{
+		Name: "math",
+		Actions: []nucleo.Action{{
+			Name:     "add",
+			Params:   map[string]interface{}{"a": "required,number"},
+			Settings: map[string]interface{}{"rateLimit": map[string]interface{}{"rate": 100}},
+			Handler: func(context nucleo.Context, params nucleo.Payload) interface{} {
+				return params.Get("a").Int() + 1
+			},
+		}},
+	})
+	bkr.Start()
+	defer bkr.Stop()
+
+	result := <-bkr.Call("math.add", map[string]interface{}{})
+	if validationError, isValidation := result.Value().(errors.NucleoValidationError); !isValidation || validationError.Type != "VALIDATION_ERROR" {
+		t.Fatalf("expected a VALIDATION_ERROR, got %v", result.Value())
+	}
+
+	if result := <-bkr.Call("math.add", map[string]interface{}{"a": 1}); result.IsError() || result.Int() != 2 {
+		t.Fatalf("expected valid params to be allowed, got %v", result.Value())
+	}
+}
@@ -0,0 +1,135 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/cache"
+	"github.com/Bendomey/nucleo-go/payload"
+	"github.com/Bendomey/nucleo-go/serializer"
+)
+
+// createCacher resolve the cacher instance from the config. Returns nil when caching is not configured,
+// or when Config.Cacher is not a cache.Cache, in which case caching is disabled and the error is logged.
+func createCacher(broker *nucleo.BrokerDelegates) cache.Cache {
+	if broker.Config.Cacher == nil {
+		return nil
+	}
+	cacher, valid := broker.Config.Cacher.(cache.Cache)
+	if !valid {
+		broker.Logger("cacher", "").Errorln(fmt.Sprintf("Invalid Config.Cacher: %T does not implement cache.Cache, caching is disabled", broker.Config.Cacher))
+		return nil
+	}
+	cacher.SetLogger(broker.Logger("cacher", ""))
+	cacher.SetSerializer(serializer.New(broker))
+	return cacher
+}
+
+// Cache return the cacher used by the registry, nil when caching is disabled.
+func (registry *ServiceRegistry) Cache() cache.Cache {
+	return registry.cache
+}
+
+// actionCacheKey check if the action has caching enabled and return the cache key for this call.
+func (registry *ServiceRegistry) actionCacheKey(context nucleo.BrokerContext, actionEntry *ActionEntry) (string, cache.ActionOptions, bool) {
+	if registry.cache == nil {
+		return "", cache.ActionOptions{}, false
+	}
+	options, enabled := cache.ActionSettings(actionEntry.action.Settings())
+	if !enabled {
+		return "", options, false
+	}
+	key := registry.cache.GetCacheKey(context.ActionName(), context.Payload(), context.Meta(), options.Keys)
+	return key, options, true
+}
+
+// invokeCachedLocalAction return the cached result when available, otherwise invoke the local action and store the result in the cache.
+// When the cacher has locking enabled only one caller invokes the action for a missing key, the others wait and read the cached result.
+func (registry *ServiceRegistry) invokeCachedLocalAction(context nucleo.BrokerContext, actionEntry *ActionEntry) nucleo.Payload {
+	key, options, enabled := registry.actionCacheKey(context, actionEntry)
+	if !enabled {
+		return registry.invokeLocalAction(context, actionEntry)
+	}
+	lock := registry.cache.LockOptions()
+	if !lock.Enabled {
+		if cached, found := registry.cache.Get(key); found {
+			registry.logger.Debugln("invokeCachedLocalAction() cache hit - action: ", context.ActionName(), " key: ", key)
+			return cached
+		}
+		return registry.invokeAndCache(context, actionEntry, key, options)
+	}
+
+	if cached, ttl, found := registry.cache.GetWithTTL(key); found {
+		registry.logger.Debugln("invokeCachedLocalAction() cache hit - action: ", context.ActionName(), " key: ", key)
+		if lock.IsStale(ttl) {
+			go registry.revalidate(context, actionEntry, key, options)
+		}
+		return cached
+	}
+
+	unlock, acquired := registry.cache.Lock(key, lock.TTL)
+	defer unlock()
+	if acquired {
+		// another caller may have computed the value while we waited for the lock
+		if cached, found := registry.cache.Get(key); found {
+			registry.logger.Debugln("invokeCachedLocalAction() cache hit after lock - action: ", context.ActionName(), " key: ", key)
+			return cached
+		}
+	} else {
+		registry.logger.Warnln("invokeCachedLocalAction() lock timeout, invoking action without lock - action: ", context.ActionName(), " key: ", key)
+	}
+	return registry.invokeAndCache(context, actionEntry, key, options)
+}
+
+// revalidate refresh a stale cache entry in the background. Only one caller refreshes the entry, the others keep serving the stale value.
+func (registry *ServiceRegistry) revalidate(context nucleo.BrokerContext, actionEntry *ActionEntry, key string, options cache.ActionOptions) {
+	unlock, acquired := registry.cache.Lock(key, 0)
+	if !acquired {
+		return
+	}
+	defer unlock()
+	registry.logger.Debugln("revalidate() refreshing stale entry - action: ", context.ActionName(), " key: ", key)
+	registry.invokeAndCache(context, actionEntry, key, options)
+}
+
+func (registry *ServiceRegistry) invokeAndCache(context nucleo.BrokerContext, actionEntry *ActionEntry, key string, options cache.ActionOptions) nucleo.Payload {
+	result := registry.invokeLocalAction(context, actionEntry)
+	if !result.IsError() {
+		registry.cache.Set(key, result, options.TTL)
+	}
+	return result
+}
+
+// subscribeCacherEvents listen to the $cacher.clean and $cacher.del internal events.
+// These events are broadcasted to all nodes, so every local cacher is invalidated.
+func (registry *ServiceRegistry) subscribeCacherEvents() {
+	if registry.cache == nil {
+		return
+	}
+	registry.broker.Bus().On("$cacher.clean", func(args ...interface{}) {
+		params := eventParams(args)
+		pattern := params.Get("pattern").String()
+		registry.logger.Debugln("$cacher.clean event - pattern: ", pattern)
+		registry.cache.Clean(pattern)
+	})
+	registry.broker.Bus().On("$cacher.del", func(args ...interface{}) {
+		params := eventParams(args)
+		keys := params.Get("keys")
+		if !keys.IsArray() {
+			keys = payload.New([]string{keys.String()})
+		}
+		registry.logger.Debugln("$cacher.del event - keys: ", keys)
+		for _, key := range keys.StringArray() {
+			registry.cache.Del(key)
+		}
+	})
+}
+
+func eventParams(args []interface{}) nucleo.Payload {
+	if len(args) > 0 {
+		if params, valid := args[0].(nucleo.Payload); valid {
+			return params
+		}
+	}
+	return payload.Empty()
+}
@@ -19,17 +19,26 @@ type ActionEntry struct {
 	isLocal      bool
 	service      *service.Service
 	logger       *log.Entry
+	nodes        *NodeCatalog
 }
 
 type actionsMap map[string][]ActionEntry
 
 type ActionCatalog struct {
-	actions sync.Map
-	logger  *log.Entry
+	actions    sync.Map
+	strategies sync.Map
+	logger     *log.Entry
+	nodes      *NodeCatalog
+	config     nucleo.Config
 }
 
-func CreateActionCatalog(logger *log.Entry) *ActionCatalog {
-	return &ActionCatalog{actions: sync.Map{}, logger: logger}
+// actionStrategy is the strategy cached for an action, nil when the action uses the broker strategy.
+type actionStrategy struct {
+	strategy strategy.Strategy
+}
+
+func CreateActionCatalog(logger *log.Entry, nodes *NodeCatalog, config nucleo.Config) *ActionCatalog {
+	return &ActionCatalog{actions: sync.Map{}, strategies: sync.Map{}, logger: logger, nodes: nodes, config: config}
 }
 
 var actionCallRecovery = true //TODO extract this to a Config - useful to turn for Debug in tests.
@@ -94,10 +103,19 @@ func (actionEntry ActionEntry) TargetNodeID() string {
 	return actionEntry.targetNodeID
 }
 
+// Node return the node of the entry, nil when the node is no longer known.
+func (actionEntry ActionEntry) Node() nucleo.Node {
+	return findNode(actionEntry.nodes, actionEntry.targetNodeID)
+}
+
 func (actionEntry ActionEntry) IsLocal() bool {
 	return actionEntry.isLocal
 }
 
+func (actionEntry ActionEntry) Action() *service.Action {
+	return actionEntry.action
+}
+
 func (actionEntry ActionEntry) Service() *service.Service {
 	return actionEntry.service
 }
@@ -113,7 +131,7 @@ func (actionCatalog *ActionCatalog) listByName() map[string][]ActionEntry {
 
 // Add a new action to the catalog.
 func (actionCatalog *ActionCatalog) Add(action service.Action, serv *service.Service, local bool) {
-	entry := ActionEntry{serv.NodeID(), &action, local, serv, actionCatalog.logger}
+	entry := ActionEntry{serv.NodeID(), &action, local, serv, actionCatalog.logger, actionCatalog.nodes}
 	name := action.FullName()
 	ver := serv.Version()
 	if ver != "" && !strings.HasPrefix(name, ver) {
@@ -126,6 +144,7 @@ func (actionCatalog *ActionCatalog) Add(action service.Action, serv *service.Ser
 		list = append(list.([]ActionEntry), entry)
 	}
 	actionCatalog.actions.Store(name, list)
+	actionCatalog.strategies.Delete(name)
 }
 
 func (actionCatalog *ActionCatalog) Update(nodeID string, fullname string, updates map[string]interface{}) {
@@ -148,6 +167,7 @@ func (actionCatalog *ActionCatalog) RemoveByNode(nodeID string) {
 		} else {
 			actionCatalog.actions.Store(name, toKeep)
 		}
+		actionCatalog.strategies.Delete(name)
 		return true
 	})
 }
@@ -165,6 +185,7 @@ func (actionCatalog *ActionCatalog) Remove(nodeID string, name string) {
 		}
 	}
 	actionCatalog.actions.Store(name, toKeep)
+	actionCatalog.strategies.Delete(name)
 }
 
 func (actionCatalog *ActionCatalog) NextFromNode(actionName string, nodeID string) *ActionEntry {
@@ -193,21 +214,57 @@ func (actionCatalog *ActionCatalog) printDebugActions() {
 	actionCatalog.logger.Debugln("actions: ", strings.Join(allActions, ", "))
 }
 
+// ActionFilter decides if an action entry can be selected by Next.
+type ActionFilter func(entry ActionEntry) bool
+
+// strategyFor return the strategy configured for the action in the settings of its entries (Action.Settings["strategy"]
+// or ServiceSchema.Settings["$strategy"]), or the default strategy. Strategies are cached per action until its entries change.
+func (actionCatalog *ActionCatalog) strategyFor(actionName string, actions []ActionEntry, defaultStrategy strategy.Strategy) strategy.Strategy {
+	if cached, exists := actionCatalog.strategies.Load(actionName); exists {
+		if stg := cached.(actionStrategy).strategy; stg != nil {
+			return stg
+		}
+		return defaultStrategy
+	}
+	var stg strategy.Strategy
+	for _, action := range actions {
+		if stg = settingsStrategy(actionCatalog.config, action.action.Settings(), action.service.Settings()); stg != nil {
+			break
+		}
+	}
+	cached, _ := actionCatalog.strategies.LoadOrStore(actionName, actionStrategy{stg})
+	if stg = cached.(actionStrategy).strategy; stg != nil {
+		return stg
+	}
+	return defaultStrategy
+}
+
 // Next find all actions registered in this node and use the strategy to select and return the best one to be called.
-func (actionCatalog *ActionCatalog) Next(actionName string, stg strategy.Strategy) *ActionEntry {
+// The strategy configured in the action or service settings overrides the given strategy. The local endpoint
+// is returned when local endpoints are preferred, or when the call was received from a remote node.
+// Entries rejected by any of the filters are not considered.
+func (actionCatalog *ActionCatalog) Next(context nucleo.BrokerContext, stg strategy.Strategy, filters ...ActionFilter) *ActionEntry {
+	actionName := context.ActionName()
 	actions := actionCatalog.Find(actionName)
 	if actions == nil {
 		actionCatalog.logger.Debugln("actionCatalog.Next() action not found: ", actionName, "  actionCatalog.actions: ", actionCatalog.actions)
 		return nil
 	}
-	nodes := make([]strategy.Selector, len(actions))
-	for index, action := range actions {
-		nodes[index] = action
-		if action.IsLocal() {
+	stg = actionCatalog.strategyFor(actionName, actions, stg)
+	// requests received from another node were already load balanced by the caller, they are never sent
+	// to a third node, otherwise a request could bounce between the nodes.
+	local := preferLocal(actionCatalog.config, stg) || context.SourceNodeID() != ""
+	nodes := make([]strategy.Selector, 0, len(actions))
+	for _, action := range actions {
+		if !acceptAction(action, filters) {
+			continue
+		}
+		if action.IsLocal() && local {
 			return &action
 		}
+		nodes = append(nodes, action)
 	}
-	if selected := stg.Select(nodes); selected != nil {
+	if selected := stg.Select(context, nodes); selected != nil {
 		entry := (*selected).(ActionEntry)
 		return &entry
 	}
@@ -215,6 +272,15 @@ func (actionCatalog *ActionCatalog) Next(actionName string, stg strategy.Strateg
 	return nil
 }
 
+func acceptAction(action ActionEntry, filters []ActionFilter) bool {
+	for _, filter := range filters {
+		if !filter(action) {
+			return false
+		}
+	}
+	return true
+}
+
 func (actionCatalog *ActionCatalog) Find(name string) []ActionEntry {
 	list, exists := actionCatalog.actions.Load(name)
 	if !exists {
@@ -0,0 +1,37 @@
+package registry_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/broker"
+	"github.com/Bendomey/nucleo-go/transit/memory"
+)
+
+// startBroker start a broker connected to the shared memory bus, stopped when the test ends.
+func startBroker(t *testing.T, bus *memory.SharedMemory, nodeID string, config nucleo.Config, services ...nucleo.ServiceSchema) *broker.ServiceBroker {
+	config.LogLevel = nucleo.LogLevelFatal
+	config.DiscoverNodeID = func() string { return nodeID }
+	config.TransporterOptions = map[string]interface{}{"bus": bus}
+	bkr := broker.New(&config)
+	for _, service := range services {
+		bkr.PublishServices(service)
+	}
+	bkr.Start()
+	t.Cleanup(bkr.Stop)
+	return bkr
+}
+
+// waitForNodes wait until the action is available on the given number of nodes.
+func waitForNodes(t *testing.T, bkr *broker.ServiceBroker, actionName string, count int) {
+	for start := time.Now(); time.Since(start) < 2*time.Second; time.Sleep(10 * time.Millisecond) {
+		result := <-bkr.Call("$node.actions", map[string]interface{}{"onlyAvailable": true, "withEndpoints": true})
+		for _, action := range result.Array() {
+			if action.Get("name").String() == actionName && action.Get("endpoints").Len() >= count {
+				return
+			}
+		}
+	}
+	t.Fatalf("expected %s to be available on %d nodes", actionName, count)
+}
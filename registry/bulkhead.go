@@ -0,0 +1,142 @@
+package registry
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/errors"
+	"github.com/Bendomey/nucleo-go/payload"
+)
+
+// bulkhead limit the concurrent invocations of a local action.
+// The slots channel holds one value per running invocation, callers waiting for a slot are counted in queued.
+type bulkhead struct {
+	options nucleo.Bulkhead
+	slots   chan bool
+	queued  int
+	mutex   *sync.Mutex
+}
+
+func createBulkhead(options nucleo.Bulkhead) *bulkhead {
+	concurrency := options.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &bulkhead{options: options, slots: make(chan bool, concurrency), mutex: &sync.Mutex{}}
+}
+
+type acquireResult int
+
+const (
+	slotAcquired acquireResult = iota
+	queueIsFull
+	queueTimeout
+)
+
+// acquire take a free slot or wait in the queue for one until the deadline, a zero deadline waits until a
+// slot is released. At most MaxQueueSize callers wait in the queue, the others are rejected right away.
+func (bulkhead *bulkhead) acquire(deadline time.Time) acquireResult {
+	select {
+	case bulkhead.slots <- true:
+		return slotAcquired
+	default:
+	}
+
+	bulkhead.mutex.Lock()
+	if bulkhead.queued >= bulkhead.options.MaxQueueSize {
+		bulkhead.mutex.Unlock()
+		return queueIsFull
+	}
+	bulkhead.queued++
+	bulkhead.mutex.Unlock()
+	defer func() {
+		bulkhead.mutex.Lock()
+		bulkhead.queued--
+		bulkhead.mutex.Unlock()
+	}()
+
+	var expired <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case bulkhead.slots <- true:
+		return slotAcquired
+	case <-expired:
+		return queueTimeout
+	}
+}
+
+func (bulkhead *bulkhead) release() {
+	<-bulkhead.slots
+}
+
+// bulkheadOptions merge the global bulkhead config with the "bulkhead" entry of the action settings.
+// The setting accepts true/false or a map with "enabled", "concurrency" and "maxQueueSize".
+func bulkheadOptions(global nucleo.Bulkhead, settings map[string]interface{}) nucleo.Bulkhead {
+	options := global
+	if settings == nil || settings["bulkhead"] == nil {
+		return options
+	}
+	if enabled, isBool := settings["bulkhead"].(bool); isBool {
+		options.Enabled = enabled
+		return options
+	}
+	values := payload.New(settings["bulkhead"])
+	if !values.IsMap() {
+		return options
+	}
+	options.Enabled = true
+	if values.Get("enabled").Exists() {
+		options.Enabled = values.Get("enabled").Bool()
+	}
+	if values.Get("concurrency").Exists() {
+		options.Concurrency = values.Get("concurrency").Int()
+	}
+	if values.Get("maxQueueSize").Exists() {
+		options.MaxQueueSize = values.Get("maxQueueSize").Int()
+	}
+	return options
+}
+
+// actionBulkhead return the bulkhead of the local action, nil when the bulkhead is disabled for it.
+func (registry *ServiceRegistry) actionBulkhead(actionEntry *ActionEntry) *bulkhead {
+	name := actionEntry.action.FullName()
+	if value, exists := registry.bulkheads.Load(name); exists {
+		return value.(*bulkhead)
+	}
+	options := bulkheadOptions(registry.broker.Config.Bulkhead, actionEntry.action.Settings())
+	if !options.Enabled {
+		registry.bulkheads.Store(name, (*bulkhead)(nil))
+		return nil
+	}
+	value, _ := registry.bulkheads.LoadOrStore(name, createBulkhead(options))
+	return value.(*bulkhead)
+}
+
+// invokeLocalAction invoke the local action respecting its bulkhead.
+func (registry *ServiceRegistry) invokeLocalAction(context nucleo.BrokerContext, actionEntry *ActionEntry) nucleo.Payload {
+	bulkhead := registry.actionBulkhead(actionEntry)
+	if bulkhead == nil {
+		return <-actionEntry.invokeLocalAction(context)
+	}
+	switch bulkhead.acquire(context.Deadline()) {
+	case queueTimeout:
+		registry.logger.Warnln("invokeLocalAction() timed out waiting in the bulkhead queue - action: ", context.ActionName())
+		return timeoutError(context, registry.localNode.GetID())
+	case queueIsFull:
+		registry.logger.Warnln("invokeLocalAction() bulkhead queue is full - action: ", context.ActionName())
+		err := errors.NewQueueIsFullError(errors.NewQueueIsFullErrorInput{
+			Action:       context.ActionName(),
+			NodeID:       registry.localNode.GetID(),
+			Concurrency:  bulkhead.options.Concurrency,
+			MaxQueueSize: bulkhead.options.MaxQueueSize,
+		})
+		return payload.New(&err)
+	}
+	defer bulkhead.release()
+	return <-actionEntry.invokeLocalAction(context)
+}
@@ -0,0 +1,104 @@
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+)
+
+func TestBulkheadConcurrencyLimit(t *testing.T) {
+	bulkhead := createBulkhead(nucleo.Bulkhead{Enabled: true, Concurrency: 2, MaxQueueSize: 1})
+	for index := 0; index < 2; index++ {
+		if result := bulkhead.acquire(time.Time{}); result != slotAcquired {
+			t.Fatalf("expected a slot for call %d, got %d", index, result)
+		}
+	}
+
+	acquired := make(chan acquireResult, 1)
+	go func() {
+		acquired <- bulkhead.acquire(time.Time{})
+	}()
+	select {
+	case result := <-acquired:
+		t.Fatalf("expected the third call to wait for a slot, got %d", result)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	bulkhead.release()
+	select {
+	case result := <-acquired:
+		if result != slotAcquired {
+			t.Fatalf("expected the queued call to get the released slot, got %d", result)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the queued call to get the released slot")
+	}
+}
+
+func TestBulkheadQueueIsFull(t *testing.T) {
+	bulkhead := createBulkhead(nucleo.Bulkhead{Enabled: true, Concurrency: 1, MaxQueueSize: 1})
+	bulkhead.acquire(time.Time{})
+	go bulkhead.acquire(time.Now().Add(time.Second))
+	for start := time.Now(); bulkhead.queuedCalls() == 0; time.Sleep(time.Millisecond) {
+		if time.Since(start) > time.Second {
+			t.Fatal("expected a call in the queue")
+		}
+	}
+
+	if result := bulkhead.acquire(time.Time{}); result != queueIsFull {
+		t.Fatalf("expected the call to be rejected, got %d", result)
+	}
+
+	noQueue := createBulkhead(nucleo.Bulkhead{Enabled: true, Concurrency: 1})
+	noQueue.acquire(time.Time{})
+	if result := noQueue.acquire(time.Time{}); result != queueIsFull {
+		t.Fatalf("expected the call to be rejected without a queue, got %d", result)
+	}
+}
+
+func TestBulkheadQueueTimeout(t *testing.T) {
+	bulkhead := createBulkhead(nucleo.Bulkhead{Enabled: true, Concurrency: 1, MaxQueueSize: 10})
+	bulkhead.acquire(time.Time{})
+
+	start := time.Now()
+	if result := bulkhead.acquire(start.Add(50 * time.Millisecond)); result != queueTimeout {
+		t.Fatalf("expected the queued call to expire, got %d", result)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("expected the call to wait until its deadline, waited %s", elapsed)
+	}
+	if queued := bulkhead.queuedCalls(); queued != 0 {
+		t.Fatalf("expected the expired call to leave the queue, got %d", queued)
+	}
+
+	bulkhead.release()
+	if result := bulkhead.acquire(time.Now().Add(50 * time.Millisecond)); result != slotAcquired {
+		t.Fatalf("expected the expired call to not take the slot, got %d", result)
+	}
+}
+
+func TestBulkheadOptions(t *testing.T) {
+	global := nucleo.Bulkhead{Enabled: false, Concurrency: 5, MaxQueueSize: 10}
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		want     nucleo.Bulkhead
+	}{
+		{"no settings", nil, global},
+		{"enabled", map[string]interface{}{"bulkhead": true}, nucleo.Bulkhead{Enabled: true, Concurrency: 5, MaxQueueSize: 10}},
+		{"map", map[string]interface{}{"bulkhead": map[string]interface{}{"concurrency": 2}}, nucleo.Bulkhead{Enabled: true, Concurrency: 2, MaxQueueSize: 10}},
+		{"disabled map", map[string]interface{}{"bulkhead": map[string]interface{}{"enabled": false, "maxQueueSize": 1}}, nucleo.Bulkhead{Enabled: false, Concurrency: 5, MaxQueueSize: 1}},
+	}
+	for _, test := range tests {
+		if got := bulkheadOptions(global, test.settings); got != test.want {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.want, got)
+		}
+	}
+}
+
+func (bulkhead *bulkhead) queuedCalls() int {
+	bulkhead.mutex.Lock()
+	defer bulkhead.mutex.Unlock()
+	return bulkhead.queued
+}
@@ -0,0 +1,64 @@
+//go:build linux
+
+package registry
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// procStatSampler read the host cpu times from /proc/stat.
+type procStatSampler struct {
+	idle  uint64
+	total uint64
+}
+
+func createCPUSampler() cpuSampler {
+	return &procStatSampler{}
+}
+
+func (sampler *procStatSampler) sample() (float64, bool) {
+	idle, total, ok := readProcStat()
+	if !ok {
+		return 0, false
+	}
+	idleDelta := float64(idle - sampler.idle)
+	totalDelta := float64(total - sampler.total)
+	sampler.idle = idle
+	sampler.total = total
+	if totalDelta <= 0 {
+		return 0, false
+	}
+	return 1 - idleDelta/totalDelta, true
+}
+
+// readProcStat return the idle (idle + iowait) and total cpu times of the aggregated cpu line.
+func readProcStat() (idle, total uint64, ok bool) {
+	file, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0, 0, false
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		return 0, 0, false
+	}
+	fields := strings.Fields(scanner.Text())
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0, 0, false
+	}
+	for index, field := range fields[1:] {
+		value, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+		total += value
+		// idle and iowait columns
+		if index == 3 || index == 4 {
+			idle += value
+		}
+	}
+	return idle, total, true
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package registry
+
+// createCPUSampler return nil as the cpu usage is not measured on this platform.
+func createCPUSampler() cpuSampler {
+	return nil
+}
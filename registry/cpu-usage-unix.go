@@ -0,0 +1,36 @@
+//go:build unix && !linux
+
+package registry
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// rusageSampler measure the cpu time used by the process, as the host cpu times are not portable.
+type rusageSampler struct {
+	cpuTime time.Duration
+	last    time.Time
+}
+
+func createCPUSampler() cpuSampler {
+	return &rusageSampler{last: time.Now()}
+}
+
+func (sampler *rusageSampler) sample() (float64, bool) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, false
+	}
+	now := time.Now()
+	cpuTime := time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+	elapsed := now.Sub(sampler.last) * time.Duration(runtime.NumCPU())
+	cpuDelta := cpuTime - sampler.cpuTime
+	sampler.cpuTime = cpuTime
+	sampler.last = now
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return float64(cpuDelta) / float64(elapsed), true
+}
@@ -0,0 +1,36 @@
+package registry
+
+import (
+	"math"
+	"sync"
+)
+
+// cpuUsage measure the cpu usage of the host between two calls.
+type cpuUsage struct {
+	sampler cpuSampler
+	mutex   *sync.Mutex
+}
+
+func createCPUUsage() *cpuUsage {
+	return &cpuUsage{sampler: createCPUSampler(), mutex: &sync.Mutex{}}
+}
+
+// measure return the cpu usage (0-100) since the last call. The first call returns the usage since
+// the sampler reference point (boot time on linux, process start on other systems).
+func (usage *cpuUsage) measure() (int64, bool) {
+	if usage == nil || usage.sampler == nil {
+		return 0, false
+	}
+	usage.mutex.Lock()
+	defer usage.mutex.Unlock()
+	value, measured := usage.sampler.sample()
+	if !measured {
+		return 0, false
+	}
+	return int64(math.Round(math.Max(0, math.Min(100, value*100)))), true
+}
+
+// cpuSampler return the cpu usage ratio (0-1) since the previous sample.
+type cpuSampler interface {
+	sample() (float64, bool)
+}
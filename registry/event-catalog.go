@@ -16,12 +16,18 @@ type EventEntry struct {
 	service      *service.Service
 	event        *service.Event
 	isLocal      bool
+	nodes        *NodeCatalog
 }
 
 func (eventEntry EventEntry) TargetNodeID() string {
 	return eventEntry.targetNodeID
 }
 
+// Node return the node of the entry, nil when the node is no longer known.
+func (eventEntry EventEntry) Node() nucleo.Node {
+	return findNode(eventEntry.nodes, eventEntry.targetNodeID)
+}
+
 func (eventEntry *EventEntry) IsLocal() bool {
 	return eventEntry.isLocal
 }
@@ -51,16 +57,17 @@ func (eventEntry *EventEntry) emitLocalEvent(context nucleo.BrokerContext) {
 type EventCatalog struct {
 	events sync.Map
 	logger *log.Entry
+	nodes  *NodeCatalog
 }
 
-func CreateEventCatalog(logger *log.Entry) *EventCatalog {
+func CreateEventCatalog(logger *log.Entry, nodes *NodeCatalog) *EventCatalog {
 	events := sync.Map{}
-	return &EventCatalog{events: events, logger: logger}
+	return &EventCatalog{events: events, logger: logger, nodes: nodes}
 }
 
 // Add a new event to the catalog.
 func (eventCatalog *EventCatalog) Add(event service.Event, service *service.Service, local bool) {
-	entry := EventEntry{service.NodeID(), service, &event, local}
+	entry := EventEntry{service.NodeID(), service, &event, local, eventCatalog.nodes}
 	name := event.Name()
 	eventCatalog.logger.Debugln("Add event name: ", name, " serviceName: ", event.ServiceName())
 	list, exists := eventCatalog.events.Load(name)
@@ -143,7 +150,7 @@ func findLocal(events []EventEntry) *EventEntry {
 }
 
 // Find find all events registered in this node and use the strategy to select and return the best one to be called.
-func (eventCatalog *EventCatalog) Find(name string, groups []string, preferLocal bool, localOnly bool, stg strategy.Strategy) []*EventEntry {
+func (eventCatalog *EventCatalog) Find(context nucleo.BrokerContext, name string, groups []string, preferLocal bool, localOnly bool, stg strategy.Strategy) []*EventEntry {
 	events, exists := eventCatalog.events.Load(name)
 	if !exists {
 		return make([]*EventEntry, 0)
@@ -179,7 +186,7 @@ func (eventCatalog *EventCatalog) Find(name string, groups []string, preferLocal
 				for index, entry := range entries {
 					nodes[index] = &entry
 				}
-				if selected := stg.Select(nodes); selected != nil {
+				if selected := stg.Select(context, nodes); selected != nil {
 					entry := (*selected).(*EventEntry)
 					result = append(result, entry)
 				}
@@ -0,0 +1,72 @@
+package registry
+
+import (
+	"strings"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/payload"
+)
+
+// fallbackHandler resolve the fallback of a failed call. Options.FallbackResponse has priority over
+// the "fallback" entry of the action settings. Return nil when the call has no fallback.
+// The node running the action applies its fallback, the caller applies it when the node does not answer.
+// Remote actions only export the name of a fallback action, fallback functions are local only.
+func fallbackHandler(actionEntry *ActionEntry, opts []nucleo.Options) func(context nucleo.BrokerContext, err error) nucleo.Payload {
+	if len(opts) > 0 && opts[0].FallbackResponse != nil {
+		response := opts[0].FallbackResponse
+		if handler := toFallbackFunc(response); handler != nil {
+			return invokeFallbackFunc(handler)
+		}
+		return func(context nucleo.BrokerContext, err error) nucleo.Payload {
+			return payload.New(response)
+		}
+	}
+	if actionEntry == nil || actionEntry.action.Settings() == nil {
+		return nil
+	}
+	switch fallback := actionEntry.action.Settings()["fallback"].(type) {
+	case string:
+		// name of an action, actions without the service name are resolved in the same service
+		actionName := fallback
+		if !strings.Contains(actionName, ".") && actionEntry.Service() != nil {
+			actionName = actionEntry.Service().FullName() + "." + actionName
+		}
+		return func(context nucleo.BrokerContext, err error) nucleo.Payload {
+			return <-context.Call(actionName, context.Payload())
+		}
+	default:
+		if handler := toFallbackFunc(fallback); handler != nil {
+			return invokeFallbackFunc(handler)
+		}
+	}
+	return nil
+}
+
+func toFallbackFunc(value interface{}) nucleo.FallbackFunc {
+	switch handler := value.(type) {
+	case nucleo.FallbackFunc:
+		return handler
+	case func(nucleo.Context, error) interface{}:
+		return handler
+	}
+	return nil
+}
+
+func invokeFallbackFunc(handler nucleo.FallbackFunc) func(context nucleo.BrokerContext, err error) nucleo.Payload {
+	return func(context nucleo.BrokerContext, err error) nucleo.Payload {
+		return payload.New(handler(context.(nucleo.Context), err))
+	}
+}
+
+// fallback replace the error result with the fallback response, when the call has one.
+// The original error is recorded in the context meta under "$fallbackError".
+func (registry *ServiceRegistry) fallback(context nucleo.BrokerContext, actionEntry *ActionEntry, result nucleo.Payload, opts []nucleo.Options) nucleo.Payload {
+	handler := fallbackHandler(actionEntry, opts)
+	if handler == nil {
+		return result
+	}
+	err := result.Error()
+	registry.logger.Debugln("fallback() - action: ", context.ActionName(), " error: ", err)
+	context.UpdateMeta(context.Meta().Add("$fallbackError", err.Error()))
+	return handler(context, err)
+}
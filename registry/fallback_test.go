@@ -0,0 +1,78 @@
+package registry_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/transit/memory"
+)
+
+// paymentService fail the charge action, the failure is replaced by the fallback of the action settings.
+func paymentService(fallback interface{}) nucleo.ServiceSchema {
+	return nucleo.ServiceSchema{
+		Name: "payment",
+		Actions: []nucleo.Action{
+			{
+				Name:     "charge",
+				Settings: map[string]interface{}{"fallback": fallback},
+				Handler: func(context nucleo.Context, params nucleo.Payload) interface{} {
+					if params.Get("slow").Exists() {
+						time.Sleep(200 * time.Millisecond)
+					}
+					return errors.New("gateway down")
+				},
+			},
+			{
+				Name: "queue",
+				Handler: func(context nucleo.Context, params nucleo.Payload) interface{} {
+					return "queued: " + context.Meta().Get("$fallbackError").String()
+				},
+			},
+		},
+	}
+}
+
+func TestFallbackAction(t *testing.T) {
+	bus := memory.NewBus()
+	startBroker(t, bus, "worker", nucleo.Config{}, paymentService("queue"))
+	client := startBroker(t, bus, "client", nucleo.Config{})
+	waitForNodes(t, client, "payment.charge", 1)
+
+	// the node running the action applies the fallback
+	result := <-client.Call("payment.charge", nil)
+	if result.IsError() || result.String() != "queued: gateway down" {
+		t.Fatalf("expected the fallback action with the original error, got %v", result.Value())
+	}
+
+	// the fallback of the remote action is exported with its settings, the caller applies it when the node does not answer
+	result = <-client.Call("payment.charge", map[string]interface{}{"slow": true}, nucleo.Options{Timeout: 50 * time.Millisecond})
+	if result.IsError() || !strings.HasPrefix(result.String(), "queued: ") || result.String() == "queued: gateway down" {
+		t.Fatalf("expected the fallback action with the timeout error, got %v", result.Value())
+	}
+}
+
+func TestFallbackFunc(t *testing.T) {
+	var fallbackError string
+	fallback := func(context nucleo.Context, err error) interface{} {
+		fallbackError = context.Meta().Get("$fallbackError").String()
+		return "pending"
+	}
+	client := startBroker(t, memory.NewBus(), "client", nucleo.Config{}, paymentService(fallback))
+
+	result := <-client.Call("payment.charge", nil)
+	if result.IsError() || result.String() != "pending" {
+		t.Fatalf("expected the fallback response, got %v", result.Value())
+	}
+	if fallbackError != "gateway down" {
+		t.Fatalf("expected the original error in the $fallbackError meta, got %q", fallbackError)
+	}
+
+	// the option has priority over the action settings
+	result = <-client.Call("payment.charge", nil, nucleo.Options{FallbackResponse: "cached"})
+	if result.String() != "cached" {
+		t.Fatalf("expected the fallback response of the options, got %v", result.Value())
+	}
+}
@@ -0,0 +1,79 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/cache"
+	"github.com/Bendomey/nucleo-go/payload"
+	"github.com/Bendomey/nucleo-go/stream"
+)
+
+// hedgeDelay resolve the hedge delay of the call: Options.HedgeDelay, then Action.Settings["hedge"].
+// The setting accepts a delay (time.Duration or milliseconds) or a map with "delay" and "enabled". Zero disables hedging.
+func hedgeDelay(actionEntry *ActionEntry, opts []nucleo.Options) time.Duration {
+	if len(opts) > 0 && opts[0].HedgeDelay > 0 {
+		return opts[0].HedgeDelay
+	}
+	settings := actionEntry.action.Settings()
+	if settings == nil || settings["hedge"] == nil {
+		return 0
+	}
+	values := payload.New(settings["hedge"])
+	if !values.IsMap() {
+		return cache.ToDuration(settings["hedge"], time.Millisecond)
+	}
+	if values.Get("enabled").Exists() && !values.Get("enabled").Bool() {
+		return 0
+	}
+	return cache.ToDuration(values.Get("delay").Value(), time.Millisecond)
+}
+
+// invokeHedgedRemoteAction invoke the remote action and, when it does not answer within the hedge delay,
+// send a duplicate request to another node. The first successful response wins and the pending request
+// of the other node is canceled.
+func (registry *ServiceRegistry) invokeHedgedRemoteAction(context nucleo.BrokerContext, actionEntry *ActionEntry, opts []nucleo.Options) nucleo.Payload {
+	delay := hedgeDelay(actionEntry, opts)
+	if delay <= 0 || (len(opts) > 0 && opts[0].NodeID != "") || stream.IsStream(context.Payload().Value()) {
+		return <-registry.invokeRemoteAction(context, actionEntry)
+	}
+
+	primary := registry.invokeRemoteAction(context, actionEntry)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case result := <-primary:
+		return result
+	case <-timer.C:
+	}
+
+	remoteOnly := func(entry ActionEntry) bool { return !entry.IsLocal() }
+	hedgeEntry := registry.nextAction(context, registry.strategy, opts, excludeNodes([]string{actionEntry.TargetNodeID()}), remoteOnly)
+	if hedgeEntry == nil {
+		registry.logger.Debugln("invokeHedgedRemoteAction() - no other node available for action: ", context.ActionName())
+		return <-primary
+	}
+	hedgeContext := attemptContext(context)
+	registry.logger.Debugln("invokeHedgedRemoteAction() - action: ", context.ActionName(), " no response from: ", actionEntry.TargetNodeID(), " after: ", delay, " sending hedged request to: ", hedgeEntry.TargetNodeID())
+	secondary := registry.invokeRemoteAction(hedgeContext, hedgeEntry)
+
+	var result nucleo.Payload
+	for pending := 2; pending > 0; pending-- {
+		select {
+		case result = <-primary:
+			primary = nil
+			if !result.IsError() {
+				registry.transit.CancelRequest(hedgeContext)
+				return result
+			}
+		case result = <-secondary:
+			secondary = nil
+			if !result.IsError() {
+				registry.transit.CancelRequest(context)
+				context.SetTargetNodeID(hedgeEntry.TargetNodeID())
+				return result
+			}
+		}
+	}
+	return result
+}
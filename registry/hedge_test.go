@@ -0,0 +1,90 @@
+package registry_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/transit/memory"
+)
+
+type hedgedCall struct {
+	nodeID string
+	id     string
+	level  int
+}
+
+// searchService answer slowly to the first call it receives across all the nodes.
+func searchService(nodeID string, calls *int32, received chan hedgedCall) nucleo.ServiceSchema {
+	return nucleo.ServiceSchema{
+		Name: "search",
+		Actions: []nucleo.Action{{
+			Name: "find",
+			Handler: func(context nucleo.Context, params nucleo.Payload) interface{} {
+				brokerContext := context.(nucleo.BrokerContext)
+				received <- hedgedCall{nodeID, brokerContext.ID(), brokerContext.Level()}
+				if atomic.AddInt32(calls, 1) == 1 {
+					time.Sleep(300 * time.Millisecond)
+					return "slow " + nodeID
+				}
+				return "fast " + nodeID
+			},
+		}},
+	}
+}
+
+func TestHedgedCallUsesFirstResponse(t *testing.T) {
+	bus := memory.NewBus()
+	var calls int32
+	received := make(chan hedgedCall, 10)
+	startBroker(t, bus, "worker1", nucleo.Config{}, searchService("worker1", &calls, received))
+	startBroker(t, bus, "worker2", nucleo.Config{}, searchService("worker2", &calls, received))
+	client := startBroker(t, bus, "client", nucleo.Config{})
+	waitForNodes(t, client, "search.find", 2)
+
+	start := time.Now()
+	result := <-client.Call("search.find", nil, nucleo.Options{HedgeDelay: 50 * time.Millisecond})
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Fatalf("expected the hedged request to answer before the slow node, took %s", elapsed)
+	}
+	primary, hedged := <-received, <-received
+	if result.IsError() || result.String() != "fast "+hedged.nodeID {
+		t.Fatalf("expected the response of the hedged request, got %v", result.Value())
+	}
+	if primary.nodeID == hedged.nodeID {
+		t.Fatalf("expected the hedged request to be sent to another node, both went to %s", primary.nodeID)
+	}
+	// the hedged request is another attempt of the same call
+	if primary.id == hedged.id || primary.level != hedged.level {
+		t.Fatalf("expected a new context id at the same level, got %+v and %+v", primary, hedged)
+	}
+
+	// the late response of the slow node is ignored and does not answer another call
+	time.Sleep(300 * time.Millisecond)
+	result = <-client.Call("search.find", nil, nucleo.Options{NodeID: primary.nodeID})
+	if result.String() != "fast "+primary.nodeID {
+		t.Fatalf("expected the response of the new call, got %v", result.Value())
+	}
+	if calls := atomic.LoadInt32(&calls); calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestHedgedCallWithoutDelay(t *testing.T) {
+	bus := memory.NewBus()
+	var calls int32
+	received := make(chan hedgedCall, 10)
+	startBroker(t, bus, "worker1", nucleo.Config{}, searchService("worker1", &calls, received))
+	startBroker(t, bus, "worker2", nucleo.Config{}, searchService("worker2", &calls, received))
+	client := startBroker(t, bus, "client", nucleo.Config{})
+	waitForNodes(t, client, "search.find", 2)
+
+	result := <-client.Call("search.find", nil)
+	if first := <-received; result.String() != "slow "+first.nodeID {
+		t.Fatalf("expected the response of the only request, got %v", result.Value())
+	}
+	if calls := atomic.LoadInt32(&calls); calls != 1 {
+		t.Fatalf("expected a single request without hedging, got %d", calls)
+	}
+}
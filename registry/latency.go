@@ -0,0 +1,55 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+)
+
+// latencyOptions return the latency collector options. The collector is enabled when the latency strategy is used.
+func (registry *ServiceRegistry) latencyOptions() nucleo.Latency {
+	options := registry.broker.Config.Latency
+	if registry.broker.Config.Strategy == nucleo.StrategyLatency && registry.broker.Config.StrategyFactory == nil {
+		options.Enabled = true
+	}
+	return options
+}
+
+// Ping send a PING to the node and return the round trip (elapsedTime) and clock difference (timeDiff) in milliseconds.
+func (registry *ServiceRegistry) Ping(nodeID string, timeout time.Duration) chan nucleo.Payload {
+	return registry.transit.Ping(nodeID, timeout)
+}
+
+// collectLatency ping all available remote nodes and update their average latency.
+func (registry *ServiceRegistry) collectLatency() {
+	options := registry.latencyOptions()
+	for _, node := range registry.nodes.list() {
+		if node.GetID() == registry.localNode.GetID() || !node.IsAvailable() {
+			continue
+		}
+		go registry.measureLatency(node, options)
+	}
+}
+
+// measureLatency ping the node and update its latency with an exponentially weighted moving average.
+func (registry *ServiceRegistry) measureLatency(node nucleo.Node, options nucleo.Latency) {
+	result := <-registry.Ping(node.GetID(), options.PingInterval)
+	if result.IsError() {
+		registry.logger.Debugln("measureLatency() - nodeID: ", node.GetID(), " error: ", result.Error())
+		return
+	}
+	sample := time.Duration(result.Get("elapsedTime").Int64()) * time.Millisecond
+	if sample <= 0 {
+		// responses faster than the millisecond resolution of the PONG
+		sample = time.Microsecond * 500
+	}
+	registry.logger.Traceln("measureLatency() - nodeID: ", node.GetID(), " sample: ", sample)
+	node.UpdateLatency(ewma(node.GetLatency(), sample, options.Alpha))
+}
+
+func ewma(average, sample time.Duration, alpha float64) time.Duration {
+	if average <= 0 {
+		return sample
+	}
+	return time.Duration(alpha*float64(sample) + (1-alpha)*float64(average))
+}
@@ -0,0 +1,26 @@
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEWMA(t *testing.T) {
+	tests := []struct {
+		name    string
+		average time.Duration
+		sample  time.Duration
+		alpha   float64
+		want    time.Duration
+	}{
+		{"first sample", 0, 30 * time.Millisecond, 0.2, 30 * time.Millisecond},
+		{"weighted", 100 * time.Millisecond, 50 * time.Millisecond, 0.2, 90 * time.Millisecond},
+		{"only new sample", 100 * time.Millisecond, 50 * time.Millisecond, 1, 50 * time.Millisecond},
+		{"only average", 100 * time.Millisecond, 50 * time.Millisecond, 0, 100 * time.Millisecond},
+	}
+	for _, test := range tests {
+		if got := ewma(test.average, test.sample, test.alpha); got != test.want {
+			t.Errorf("%s: expected %s, got %s", test.name, test.want, got)
+		}
+	}
+}
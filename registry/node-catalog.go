@@ -64,6 +64,15 @@ func (catalog *NodeCatalog) findNode(nodeID string) (nucleo.Node, bool) {
 	}
 }
 
+// findNode return the node from the catalog, nil when the catalog is not set or the node is not found.
+func findNode(catalog *NodeCatalog, nodeID string) nucleo.Node {
+	if catalog == nil {
+		return nil
+	}
+	node, _ := catalog.findNode(nodeID)
+	return node
+}
+
 // removeNode : remove a node from the catalog
 func (catalog *NodeCatalog) removeNode(nodeID string) {
 	catalog.nodes.Delete(nodeID)
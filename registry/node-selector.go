@@ -0,0 +1,35 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/strategy"
+)
+
+// preferLocal return true when local endpoints should be called instead of load balancing between all nodes.
+// Keyed strategies (e.g. shard) always select between all nodes, so each key keeps reaching the same node.
+func preferLocal(config nucleo.Config, stg strategy.Strategy) bool {
+	return (config.PreferLocal == nil || *config.PreferLocal) && !strategy.IsKeyed(stg)
+}
+
+// nodeSelector ActionFilter accepting only entries whose node metadata contains all the labels.
+func nodeSelector(labels map[string]string) ActionFilter {
+	return func(entry ActionEntry) bool {
+		node := entry.Node()
+		if node == nil {
+			return false
+		}
+		return matchLabels(node.GetMetadata(), labels)
+	}
+}
+
+func matchLabels(metadata map[string]interface{}, labels map[string]string) bool {
+	for key, value := range labels {
+		label, exists := metadata[key]
+		if !exists || fmt.Sprint(label) != value {
+			return false
+		}
+	}
+	return true
+}
@@ -5,6 +5,7 @@ import (
 	"net"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Bendomey/nucleo-go"
@@ -20,8 +21,11 @@ type Node struct {
 	client            map[string]interface{}
 	services          []map[string]interface{}
 	isAvailable       bool
+	mutex             *sync.RWMutex // guards cpu, cpuSequence, latency and metadata, read by the strategies
 	cpu               int64
 	cpuSequence       int64
+	latency           time.Duration
+	metadata          map[string]interface{}
 	lastHeartBeatTime int64
 	offlineSince      int64
 	isLocal           bool
@@ -73,6 +77,7 @@ func CreateNode(id string, local bool, logger *log.Entry) nucleo.Node {
 		logger:   logger,
 		isLocal:  local,
 		sequence: 1,
+		mutex:    &sync.RWMutex{},
 	}
 	var result nucleo.Node = &node
 	return result
@@ -97,8 +102,12 @@ func (node *Node) Update(id string, info map[string]interface{}) bool {
 	node.logger.Debugln("node.Update() node.services: ", node.services)
 
 	node.sequence = int64Field(info, "seq", 0)
+
+	node.mutex.Lock()
+	node.metadata, _ = info["metadata"].(map[string]interface{})
 	node.cpu = int64Field(info, "cpu", 0)
 	node.cpuSequence = int64Field(info, "cpuSeq", 0)
+	node.mutex.Unlock()
 
 	return reconnected
 }
@@ -150,10 +159,16 @@ func (node *Node) ExportAsMap() map[string]interface{} {
 	resultMap["hostname"] = node.hostname
 	resultMap["client"] = node.client
 	resultMap["seq"] = node.sequence
+	resultMap["available"] = node.IsAvailable()
+
+	node.mutex.RLock()
 	resultMap["cpu"] = node.cpu
 	resultMap["cpuSeq"] = node.cpuSequence
-	resultMap["available"] = node.IsAvailable()
-	resultMap["metadata"] = make(map[string]interface{})
+	resultMap["metadata"] = node.metadata
+	if node.metadata == nil {
+		resultMap["metadata"] = make(map[string]interface{})
+	}
+	node.mutex.RUnlock()
 
 	return resultMap
 }
@@ -174,8 +189,10 @@ func (node *Node) HeartBeat(heartbeat map[string]interface{}) {
 		node.isAvailable = true
 		node.offlineSince = 0
 	}
+	node.mutex.Lock()
 	node.cpu = int64Field(heartbeat, "cpu", 0)
 	node.cpuSequence = int64Field(heartbeat, "cpuSeq", 0)
+	node.mutex.Unlock()
 	node.lastHeartBeatTime = time.Now().Unix()
 }
 
@@ -183,6 +200,60 @@ func (node *Node) Publish(service map[string]interface{}) {
 	node.services = append(node.services, service)
 }
 
+// GetCPU return the last cpu usage (0-100) reported by the node.
+func (node *Node) GetCPU() int64 {
+	node.mutex.RLock()
+	defer node.mutex.RUnlock()
+	return node.cpu
+}
+
+// UpdateCPU store the cpu usage measured in the local node. The sequence changes when the value changes.
+func (node *Node) UpdateCPU(cpu int64) {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	if cpu != node.cpu {
+		node.cpu = cpu
+		node.cpuSequence++
+	}
+}
+
+// GetLatency return the average round trip to the node measured by the latency collector.
+// Zero means the latency was not measured yet.
+func (node *Node) GetLatency() time.Duration {
+	node.mutex.RLock()
+	defer node.mutex.RUnlock()
+	return node.latency
+}
+
+func (node *Node) UpdateLatency(latency time.Duration) {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	node.latency = latency
+}
+
+// GetMetadata return the metadata (labels) of the node.
+func (node *Node) GetMetadata() map[string]interface{} {
+	node.mutex.RLock()
+	defer node.mutex.RUnlock()
+	return node.metadata
+}
+
+// SetMetadata set the metadata of the local node, published to other nodes by ExportAsMap.
+func (node *Node) SetMetadata(metadata map[string]interface{}) {
+	metadata = copyMetadata(metadata)
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	node.metadata = metadata
+}
+
+func copyMetadata(metadata map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(metadata))
+	for key, value := range metadata {
+		result[key] = value
+	}
+	return result
+}
+
 func (node *Node) IsAvailable() bool {
 	return node.isLocal || node.isAvailable
 }
@@ -0,0 +1,51 @@
+package registry_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Bendomey/nucleo-go/registry"
+	log "github.com/sirupsen/logrus"
+)
+
+// the heartbeat and latency handlers update the node while the strategies read it, run with -race.
+func TestNodeConcurrentUpdates(t *testing.T) {
+	node := registry.CreateNode("worker", false, log.WithField("node", "worker"))
+	info := map[string]interface{}{
+		"ipList":   []interface{}{"127.0.0.1"},
+		"hostname": "worker",
+		"client":   map[string]interface{}{},
+		"metadata": map[string]interface{}{"zone": "a"},
+		"cpu":      float64(10),
+	}
+	node.Update("worker", info)
+
+	var group sync.WaitGroup
+	group.Add(2)
+	go func() {
+		defer group.Done()
+		for index := 0; index < 100; index++ {
+			node.HeartBeat(map[string]interface{}{"cpu": float64(index)})
+			node.UpdateLatency(time.Duration(index) * time.Millisecond)
+			node.Update("worker", info)
+		}
+	}()
+	go func() {
+		defer group.Done()
+		for index := 0; index < 100; index++ {
+			node.GetCPU()
+			node.GetLatency()
+			node.GetMetadata()
+			node.ExportAsMap()
+		}
+	}()
+	group.Wait()
+
+	if cpu := node.GetCPU(); cpu != 10 {
+		t.Fatalf("expected the cpu of the last update, got %d", cpu)
+	}
+	if zone := node.GetMetadata()["zone"]; zone != "a" {
+		t.Fatalf("expected the metadata of the last update, got %v", zone)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/cache"
+	nucleoErrors "github.com/Bendomey/nucleo-go/errors"
 	"github.com/Bendomey/nucleo-go/middleware"
 	"github.com/Bendomey/nucleo-go/payload"
 	"github.com/Bendomey/nucleo-go/service"
@@ -30,6 +32,8 @@ type ServiceRegistry struct {
 	events                *EventCatalog
 	broker                *nucleo.BrokerDelegates
 	strategy              strategy.Strategy
+	cache                 cache.Cache
+	bulkheads             sync.Map
 	stopping              bool
 	heartbeatFrequency    time.Duration
 	heartbeatTimeout      time.Duration
@@ -37,6 +41,7 @@ type ServiceRegistry struct {
 	offlineTimeout        time.Duration
 	nodeReceivedMutex     *sync.Mutex
 	namespace             string
+	cpuUsage              *cpuUsage
 }
 
 // createTransit create a transit instance based on the config.
@@ -45,37 +50,27 @@ func createTransit(broker *nucleo.BrokerDelegates) transit.Transit {
 	return transit
 }
 
-// createStrategy create a strategy instance based on the config.
-func createStrategy(broker *nucleo.BrokerDelegates) strategy.Strategy {
-	//TODO: when new strategies are addes.. adde config check here to load the right one.
-	if broker.Config.StrategyFactory != nil {
-		return broker.Config.StrategyFactory().(strategy.Strategy)
-	}
-
-	if broker.Config.Strategy == nucleo.StrategyRoundRobin {
-		return &strategy.RoundRobinStrategy{}
-	}
-
-	return strategy.RandomStrategy{}
-}
-
 func CreateRegistry(nodeID string, broker *nucleo.BrokerDelegates) *ServiceRegistry {
 	config := broker.Config
 	transit := createTransit(broker)
 	strategy := createStrategy(broker)
 	logger := broker.Logger("registry", nodeID)
 	localNode := CreateNode(nodeID, true, logger.WithField("Node", nodeID))
+	localNode.(*Node).SetMetadata(config.Metadata)
 	localNode.Unavailable()
+	nodes := CreateNodesCatalog(logger.WithField("catalog", "Nodes"))
 	registry := &ServiceRegistry{
 		broker:                broker,
 		transit:               transit,
 		strategy:              strategy,
+		cache:                 createCacher(broker),
 		logger:                logger,
 		localNode:             localNode,
-		actions:               CreateActionCatalog(logger.WithField("catalog", "Actions")),
-		events:                CreateEventCatalog(logger.WithField("catalog", "Events")),
+		actions:               CreateActionCatalog(logger.WithField("catalog", "Actions"), nodes, config),
+		events:                CreateEventCatalog(logger.WithField("catalog", "Events"), nodes),
 		services:              CreateServiceCatalog(logger.WithField("catalog", "Services")),
-		nodes:                 CreateNodesCatalog(logger.WithField("catalog", "Nodes")),
+		nodes:                 nodes,
+		cpuUsage:              createCPUUsage(),
 		heartbeatFrequency:    config.HeartbeatFrequency,
 		heartbeatTimeout:      config.HeartbeatTimeout,
 		offlineCheckFrequency: config.OfflineCheckFrequency,
@@ -93,6 +88,7 @@ func CreateRegistry(nodeID string, broker *nucleo.BrokerDelegates) *ServiceRegis
 	})
 
 	registry.setupMessageHandlers()
+	registry.subscribeCacherEvents()
 
 	return registry
 }
@@ -161,7 +157,7 @@ func (registry *ServiceRegistry) Start() {
 	registry.nodes.Add(registry.localNode)
 
 	if registry.heartbeatFrequency > 0 {
-		go registry.loopWhileAlive(registry.heartbeatFrequency, registry.transit.SendHeartbeat)
+		go registry.loopWhileAlive(registry.heartbeatFrequency, registry.sendHeartbeat)
 	}
 	if registry.heartbeatTimeout > 0 {
 		go registry.loopWhileAlive(registry.heartbeatTimeout, registry.checkExpiredRemoteNodes)
@@ -169,6 +165,14 @@ func (registry *ServiceRegistry) Start() {
 	if registry.offlineCheckFrequency > 0 {
 		go registry.loopWhileAlive(registry.offlineCheckFrequency, registry.checkOfflineNodes)
 	}
+	if latency := registry.latencyOptions(); latency.Enabled && latency.PingInterval > 0 {
+		go registry.loopWhileAlive(latency.PingInterval, registry.collectLatency)
+	}
+}
+
+// LocalAction return the entry of the action registered in the local node, nil when the action is not local.
+func (registry *ServiceRegistry) LocalAction(name string) *ActionEntry {
+	return registry.actions.NextFromNode(name, registry.localNode.GetID())
 }
 
 func (registry *ServiceRegistry) ServiceForAction(name string) []*service.Service {
@@ -194,11 +198,16 @@ func (registry *ServiceRegistry) HandleRemoteEvent(context nucleo.BrokerContext)
 	broadcast := context.IsBroadcast()
 	registry.logger.Debugln("HandleRemoteEvent() - name: ", name, " groups: ", groups)
 
+	if isCacherEvent(name) {
+		registry.emitCacherEvent(context)
+		return
+	}
+
 	var stg strategy.Strategy
 	if !broadcast {
 		stg = registry.strategy
 	}
-	entries := registry.events.Find(name, groups, true, true, stg)
+	entries := registry.events.Find(context, name, groups, true, true, stg)
 	for _, localEvent := range entries {
 		go localEvent.emitLocalEvent(context)
 	}
@@ -212,7 +221,7 @@ func (registry *ServiceRegistry) LoadBalanceEvent(context nucleo.BrokerContext)
 	eventSig := fmt.Sprint("name: ", name, " groups: ", groups)
 	registry.logger.Traceln("LoadBalanceEvent() - ", eventSig, " params: ", params)
 
-	entries := registry.events.Find(name, groups, true, false, registry.strategy)
+	entries := registry.events.Find(context, name, groups, preferLocal(registry.broker.Config, registry.strategy), false, registry.strategy)
 	if entries == nil {
 		msg := fmt.Sprint("Broker - no endpoints found for event: ", name, " it was discarded!")
 		registry.logger.Warnln(msg)
@@ -236,7 +245,12 @@ func (registry *ServiceRegistry) BroadcastEvent(context nucleo.BrokerContext) []
 	eventSig := fmt.Sprint("name: ", name, " groups: ", groups)
 	registry.logger.Traceln("BroadcastEvent() - ", eventSig, " payload: ", context.Payload())
 
-	entries := registry.events.Find(name, groups, false, false, nil)
+	if isCacherEvent(name) {
+		registry.broadcastCacherEvent(context)
+		return nil
+	}
+
+	entries := registry.events.Find(context, name, groups, false, false, nil)
 	if entries == nil {
 		msg := fmt.Sprint("Broker - no endpoints found for event: ", name, " it was discarded!")
 		registry.logger.Warnln(msg)
@@ -254,6 +268,31 @@ func (registry *ServiceRegistry) BroadcastEvent(context nucleo.BrokerContext) []
 	return entries
 }
 
+// isCacherEvent check if the event is a cache invalidation event (e.g. $cacher.clean). Other events,
+// internal ones included, are delivered through the event catalog.
+func isCacherEvent(name string) bool {
+	return strings.HasPrefix(name, "$cacher.")
+}
+
+// broadcastCacherEvent deliver a cache invalidation event to the local bus and to all available remote nodes.
+// These events are not listed in the event catalog, so they are sent to every node.
+func (registry *ServiceRegistry) broadcastCacherEvent(context nucleo.BrokerContext) {
+	registry.emitCacherEvent(context)
+	localNodeID := registry.localNode.GetID()
+	for _, node := range registry.nodes.list() {
+		if node.GetID() == localNodeID || !node.IsAvailable() {
+			continue
+		}
+		context.SetTargetNodeID(node.GetID())
+		registry.transit.Emit(context)
+	}
+}
+
+// emitCacherEvent emit the cache invalidation event on the local bus, where the cacher handlers are subscribed.
+func (registry *ServiceRegistry) emitCacherEvent(context nucleo.BrokerContext) {
+	registry.broker.Bus().EmitAsync(context.EventName(), []interface{}{context.Payload()})
+}
+
 // DelegateCall : invoke a service action and return a channel which will eventualy deliver the results ;).
 // This call might be local or remote.
 func (registry *ServiceRegistry) LoadBalanceCall(context nucleo.BrokerContext, opts ...nucleo.Options) chan nucleo.Payload {
@@ -262,7 +301,21 @@ func (registry *ServiceRegistry) LoadBalanceCall(context nucleo.BrokerContext, o
 
 	registry.logger.Traceln("LoadBalanceCall() - actionName: ", actionName, " params: ", params, " namespace: ", registry.namespace, " opts: ", opts)
 
-	actionEntry := registry.nextAction(actionName, registry.strategy, opts...)
+	maxCallLevel := registry.broker.Config.MaxCallLevel
+	if maxCallLevel > 0 && context.Level() > maxCallLevel {
+		registry.logger.Errorln("LoadBalanceCall() - max call level reached - actionName: ", actionName, " level: ", context.Level())
+		err := nucleoErrors.NewMaxCallLevelError(nucleoErrors.NewMaxCallLevelErrorInput{
+			Action:       actionName,
+			NodeID:       registry.localNode.GetID(),
+			Level:        context.Level(),
+			MaxCallLevel: maxCallLevel,
+		})
+		resultChan := make(chan nucleo.Payload, 1)
+		resultChan <- payload.New(&err)
+		return resultChan
+	}
+
+	actionEntry := registry.nextAction(context, registry.strategy, opts)
 	if actionEntry == nil {
 		msg := "Registry - endpoint not found for actionName: " + actionName
 		if registry.namespace != "" {
@@ -270,10 +323,35 @@ func (registry *ServiceRegistry) LoadBalanceCall(context nucleo.BrokerContext, o
 		}
 		registry.logger.Errorln(msg)
 		resultChan := make(chan nucleo.Payload, 1)
-		resultChan <- payload.Error(msg)
+		resultChan <- registry.fallback(context, nil, payload.Error(msg), opts)
+		return resultChan
+	}
+
+	budget := context.Deadline()
+	registry.setCallDeadline(context, actionEntry, budget, opts)
+	result := <-registry.invokeAction(context, actionEntry, opts)
+	if result.IsError() {
+		result = registry.retryCall(context, actionEntry, result, budget, opts)
+	}
+	if result.IsError() {
+		// the attempts are over, calls made by the fallback only keep the inherited deadline
+		context.SetDeadline(budget)
+		result = registry.fallback(context, actionEntry, result, opts)
+	}
+	resultChan := make(chan nucleo.Payload, 1)
+	resultChan <- result
+	return resultChan
+}
+
+// invokeAction invoke the selected action entry, running the local or remote action middlewares.
+func (registry *ServiceRegistry) invokeAction(context nucleo.BrokerContext, actionEntry *ActionEntry, opts []nucleo.Options) chan nucleo.Payload {
+	registry.logger.Debugln("invokeAction() - actionName: ", context.ActionName(), " target nodeID: ", actionEntry.TargetNodeID())
+	if isExpired(context) {
+		registry.logger.Debugln("invokeAction() - deadline reached before calling action: ", context.ActionName())
+		resultChan := make(chan nucleo.Payload, 1)
+		resultChan <- timeoutError(context, actionEntry.TargetNodeID())
 		return resultChan
 	}
-	registry.logger.Debugln("LoadBalanceCall() - actionName: ", actionName, " target nodeID: ", actionEntry.TargetNodeID())
 
 	context.SetPayloadSchema(actionEntry.action.Params().RawMap())
 	if actionEntry.isLocal {
@@ -288,7 +366,9 @@ func (registry *ServiceRegistry) LoadBalanceCall(context nucleo.BrokerContext, o
 
 		}
 
-		result := <-actionEntry.invokeLocalAction(context)
+		result := registry.withDeadline(context, func() nucleo.Payload {
+			return registry.invokeCachedLocalAction(context, actionEntry)
+		})
 		tempParams := registry.broker.MiddlewareHandler("afterLocalAction", middleware.AfterActionParams{context, result})
 		actionParams := tempParams.(middleware.AfterActionParams)
 
@@ -297,6 +377,7 @@ func (registry *ServiceRegistry) LoadBalanceCall(context nucleo.BrokerContext, o
 		return resultChan
 	}
 
+	context.SetTargetNodeID(actionEntry.TargetNodeID())
 	beforeRemoteActionResult := registry.broker.MiddlewareHandler("beforeRemoteAction", context)
 	if beforeRemoteActionResult != nil {
 		_, isValidContext := beforeRemoteActionResult.(nucleo.BrokerContext)
@@ -307,14 +388,13 @@ func (registry *ServiceRegistry) LoadBalanceCall(context nucleo.BrokerContext, o
 		}
 
 	}
-	result := <-registry.invokeRemoteAction(context, actionEntry)
+	result := registry.invokeHedgedRemoteAction(context, actionEntry, opts)
 	tempParams := registry.broker.MiddlewareHandler("afterRemoteAction", middleware.AfterActionParams{context, result})
 	actionParams := tempParams.(middleware.AfterActionParams)
 
 	resultChan := make(chan nucleo.Payload, 1)
 	resultChan <- actionParams.Result
 	return resultChan
-
 }
 
 func (registry *ServiceRegistry) emitRemoteEvent(context nucleo.BrokerContext, eventEntry *EventEntry) {
@@ -383,6 +463,14 @@ func (registry *ServiceRegistry) checkOfflineNodes() {
 	}
 }
 
+// sendHeartbeat measure the cpu usage of the local node and send the heartbeat with it.
+func (registry *ServiceRegistry) sendHeartbeat() {
+	if usage, measured := registry.cpuUsage.measure(); measured {
+		registry.localNode.UpdateCPU(usage)
+	}
+	registry.transit.SendHeartbeat()
+}
+
 // loopWhileAlive : can the delegate runction in the given frequency and stop whe  the registry is stopping
 func (registry *ServiceRegistry) loopWhileAlive(frequency time.Duration, delegate func()) {
 	for {
@@ -454,6 +542,7 @@ func (registry *ServiceRegistry) remoteNodeInfoReceived(message nucleo.Payload)
 				nil,
 				payload.Empty(),
 			)
+			serviceAction.SetSettings(newAction.Settings())
 			registry.actions.Add(serviceAction, svc, false)
 		}
 
@@ -566,13 +655,28 @@ func (registry *ServiceRegistry) notifyServiceAdded(svc map[string]string) {
 	}
 }
 
+// endpointAvailable filter out remote endpoints that should not be called, e.g. with an open circuit breaker.
+func (registry *ServiceRegistry) endpointAvailable(actionName string) ActionFilter {
+	return func(entry ActionEntry) bool {
+		if entry.IsLocal() || registry.broker.EndpointAvailable == nil {
+			return true
+		}
+		return registry.broker.EndpointAvailable(actionName, entry.TargetNodeID())
+	}
+}
+
 // nextAction it will find and return the next action to be invoked.
 // If multiple nodes that contain this action are found it will use the strategy to decide which one to use.
-func (registry *ServiceRegistry) nextAction(actionName string, strategy strategy.Strategy, opts ...nucleo.Options) *ActionEntry {
+func (registry *ServiceRegistry) nextAction(context nucleo.BrokerContext, strategy strategy.Strategy, opts []nucleo.Options, filters ...ActionFilter) *ActionEntry {
+	actionName := context.ActionName()
 	if len(opts) > 0 && opts[0].NodeID != "" {
 		return registry.actions.NextFromNode(actionName, opts[0].NodeID)
 	}
-	return registry.actions.Next(actionName, strategy)
+	if len(opts) > 0 && len(opts[0].NodeSelector) > 0 {
+		filters = append(filters, nodeSelector(opts[0].NodeSelector))
+	}
+	filters = append(filters, registry.endpointAvailable(actionName))
+	return registry.actions.Next(context, strategy, filters...)
 }
 
 func (registry *ServiceRegistry) KnownEventListeners(addNode bool) []string {
@@ -0,0 +1,100 @@
+package registry
+
+import (
+	"math"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/errors"
+	"github.com/Bendomey/nucleo-go/stream"
+)
+
+// retries return how many times a failed call can be retried, based on the retry policy and the call options.
+// Calls received from remote nodes are not retried here, the caller node is responsible for it.
+func (registry *ServiceRegistry) retries(context nucleo.BrokerContext, opts []nucleo.Options) int {
+	if context.SourceNodeID() != "" {
+		return 0
+	}
+	// a stream can only be read once
+	if stream.IsStream(context.Payload().Value()) {
+		return 0
+	}
+	retries := 0
+	if registry.broker.Config.RetryPolicy.Enabled {
+		retries = registry.broker.Config.RetryPolicy.Retries
+	}
+	if len(opts) > 0 && opts[0].Retries != 0 {
+		retries = opts[0].Retries
+	}
+	if retries < 0 {
+		return 0
+	}
+	return retries
+}
+
+func (registry *ServiceRegistry) shouldRetry(err error) bool {
+	check := registry.broker.Config.RetryPolicy.Check
+	if check == nil {
+		check = errors.IsRetryable
+	}
+	return check(err)
+}
+
+// retryDelay return the backoff delay before the given attempt (starting at 1).
+func retryDelay(policy nucleo.RetryPolicy, attempt int) time.Duration {
+	factor := math.Max(float64(policy.Factor), 1)
+	delay := float64(policy.Delay) * math.Pow(factor, float64(attempt-1))
+	if policy.MaxDelay > 0 {
+		delay = math.Min(delay, float64(policy.MaxDelay))
+	}
+	return time.Duration(delay) * time.Millisecond
+}
+
+// excludeNodes filter out the action entries from the given nodes.
+func excludeNodes(nodeIDs []string) ActionFilter {
+	return func(entry ActionEntry) bool {
+		for _, nodeID := range nodeIDs {
+			if entry.TargetNodeID() == nodeID {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// attemptContext create the context of a new attempt of the call, with its own ID, so a late response
+// to a previous attempt can't be taken as the response of this one. The attempt is the same call, not a nested one.
+func attemptContext(context nucleo.BrokerContext) nucleo.BrokerContext {
+	return context.Copy()
+}
+
+// retryCall retry a failed call using exponential backoff. On each attempt the strategy selects a new node,
+// preferring nodes that have not failed yet.
+// Each attempt gets its own request ID and timeout, limited by the deadline inherited from the parent context (budget).
+func (registry *ServiceRegistry) retryCall(context nucleo.BrokerContext, actionEntry *ActionEntry, result nucleo.Payload, budget time.Time, opts []nucleo.Options) nucleo.Payload {
+	retries := registry.retries(context, opts)
+	policy := registry.broker.Config.RetryPolicy
+	failedNodes := []string{}
+	for attempt := 1; attempt <= retries && result.IsError() && registry.shouldRetry(result.Error()); attempt++ {
+		failedNodes = append(failedNodes, actionEntry.TargetNodeID())
+		delay := retryDelay(policy, attempt)
+		registry.logger.Debugln("retryCall() - action: ", context.ActionName(), " attempt: ", attempt, "/", retries, " delay: ", delay, " error: ", result.Error())
+		time.Sleep(delay)
+
+		next := registry.nextAction(context, registry.strategy, opts, excludeNodes(failedNodes))
+		if next == nil {
+			// all nodes already failed, let the strategy pick any of them again
+			next = registry.nextAction(context, registry.strategy, opts)
+		}
+		if next == nil {
+			registry.logger.Debugln("retryCall() - no endpoint available for action: ", context.ActionName())
+			break
+		}
+		actionEntry = next
+		retryContext := attemptContext(context)
+		registry.setCallDeadline(retryContext, actionEntry, budget, opts)
+		result = <-registry.invokeAction(retryContext, actionEntry, opts)
+		context.SetTargetNodeID(actionEntry.TargetNodeID())
+	}
+	return result
+}
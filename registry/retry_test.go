@@ -0,0 +1,44 @@
+package registry_test
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/errors"
+	"github.com/Bendomey/nucleo-go/transit/memory"
+)
+
+// a retry is another attempt of the same call, it must not count as a nested call.
+func TestRetryAtMaxCallLevel(t *testing.T) {
+	bus := memory.NewBus()
+	var calls int32
+	levels := make(chan int, 2)
+	config := nucleo.Config{MaxCallLevel: 2, RetryPolicy: nucleo.RetryPolicy{Enabled: true, Retries: 2, Delay: 10}}
+	startBroker(t, bus, "worker", config, nucleo.ServiceSchema{
+		Name: "flaky",
+		Actions: []nucleo.Action{{
+			Name: "get",
+			Handler: func(context nucleo.Context, params nucleo.Payload) interface{} {
+				levels <- context.(nucleo.BrokerContext).Level()
+				if atomic.AddInt32(&calls, 1) == 1 {
+					message := "try again"
+					err := errors.NewNucleoRetryableError(errors.NewNucleoRetryableErrorInput{Message: &message})
+					return &err
+				}
+				return "done"
+			},
+		}},
+	})
+	client := startBroker(t, bus, "client", config)
+	waitForNodes(t, client, "flaky.get", 1)
+
+	// the call of the broker is at level 2, the max call level
+	result := <-client.Call("flaky.get", nil)
+	if result.IsError() || result.String() != "done" {
+		t.Fatalf("expected the retry to succeed, got %v", result.Value())
+	}
+	if first, retry := <-levels, <-levels; first != 2 || retry != 2 {
+		t.Fatalf("expected both attempts at level 2, got %d and %d", first, retry)
+	}
+}
@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/payload"
+	"github.com/Bendomey/nucleo-go/strategy"
+)
+
+// createStrategy create a strategy instance based on the config.
+func createStrategy(broker *nucleo.BrokerDelegates) strategy.Strategy {
+	if broker.Config.StrategyFactory != nil {
+		return broker.Config.StrategyFactory().(strategy.Strategy)
+	}
+	return newStrategy(broker.Config.Strategy, broker.Config, nil)
+}
+
+// newStrategy create a strategy of the given type. The options override the strategy config,
+// e.g. "key" and "virtualNodes" for the shard strategy.
+func newStrategy(strategyType nucleo.StrategyType, config nucleo.Config, options nucleo.Payload) strategy.Strategy {
+	switch strategyType {
+	case nucleo.StrategyRoundRobin:
+		return &strategy.RoundRobinStrategy{}
+	case nucleo.StrategyCPUUsage:
+		return strategy.NewCPUUsageStrategy()
+	case nucleo.StrategyLatency:
+		return strategy.NewLatencyStrategy()
+	case nucleo.StrategyShard:
+		shard := config.Shard
+		if options != nil && options.Get("key").Exists() {
+			shard.Key = options.Get("key").String()
+		}
+		if options != nil && options.Get("virtualNodes").Exists() {
+			shard.VirtualNodes = options.Get("virtualNodes").Int()
+		}
+		return strategy.NewShardStrategy(shard)
+	}
+	return strategy.RandomStrategy{}
+}
+
+// settingsStrategy create the strategy configured in the action settings ("strategy") or in the service
+// settings ("$strategy"). The setting is the strategy type, e.g. "RoundRobin", or a map with the "type"
+// and the strategy options, e.g. {"type": "Shard", "key": "params.userID"}. Return nil when none is configured.
+func settingsStrategy(config nucleo.Config, actionSettings, serviceSettings map[string]interface{}) strategy.Strategy {
+	var setting interface{}
+	if actionSettings != nil && actionSettings["strategy"] != nil {
+		setting = actionSettings["strategy"]
+	} else if serviceSettings != nil && serviceSettings["$strategy"] != nil {
+		setting = serviceSettings["$strategy"]
+	}
+	if setting == nil {
+		return nil
+	}
+	if stg, isStrategy := setting.(strategy.Strategy); isStrategy {
+		return stg
+	}
+	options := payload.New(setting)
+	if options.IsMap() {
+		return newStrategy(nucleo.StrategyType(options.Get("type").String()), config, options)
+	}
+	return newStrategy(nucleo.StrategyType(options.String()), config, nil)
+}
@@ -0,0 +1,77 @@
+package registry
+
+import (
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/cache"
+	"github.com/Bendomey/nucleo-go/errors"
+	"github.com/Bendomey/nucleo-go/payload"
+)
+
+// callTimeout resolve the timeout of one call attempt: Options.Timeout, then Action.Settings["timeout"]
+// (time.Duration or milliseconds) and finally Config.RequestTimeout. Config.RequestTimeout only applies to
+// remote calls, local calls without a timeout option or setting only keep the deadline of their caller.
+func (registry *ServiceRegistry) callTimeout(actionEntry *ActionEntry, opts []nucleo.Options) time.Duration {
+	if len(opts) > 0 && opts[0].Timeout > 0 {
+		return opts[0].Timeout
+	}
+	if settings := actionEntry.action.Settings(); settings != nil && settings["timeout"] != nil {
+		if timeout := cache.ToDuration(settings["timeout"], time.Millisecond); timeout > 0 {
+			return timeout
+		}
+	}
+	if actionEntry.isLocal {
+		return 0
+	}
+	return registry.broker.Config.RequestTimeout
+}
+
+// setCallDeadline set the deadline of the next call attempt. The deadline inherited from the parent
+// context (budget) is never extended, so nested calls get a shrinking deadline.
+func (registry *ServiceRegistry) setCallDeadline(context nucleo.BrokerContext, actionEntry *ActionEntry, budget time.Time, opts []nucleo.Options) {
+	deadline := budget
+	if timeout := registry.callTimeout(actionEntry, opts); timeout > 0 {
+		attemptDeadline := time.Now().Add(timeout)
+		if deadline.IsZero() || attemptDeadline.Before(deadline) {
+			deadline = attemptDeadline
+		}
+	}
+	context.SetDeadline(deadline)
+}
+
+func isExpired(context nucleo.BrokerContext) bool {
+	deadline := context.Deadline()
+	return !deadline.IsZero() && !time.Now().Before(deadline)
+}
+
+func timeoutError(context nucleo.BrokerContext, nodeID string) nucleo.Payload {
+	err := errors.NewRequestTimeoutError(errors.NewRequestTimeoutErrorInput{
+		Action: context.ActionName(),
+		NodeID: nodeID,
+	})
+	return payload.New(&err)
+}
+
+// withDeadline wait for the local invocation until the context deadline. When the deadline is reached
+// a RequestTimeoutError is returned and the result of the invocation is discarded. The handler is not
+// interrupted, it can read Context.Deadline() to stop its work.
+func (registry *ServiceRegistry) withDeadline(context nucleo.BrokerContext, invoke func() nucleo.Payload) nucleo.Payload {
+	deadline := context.Deadline()
+	if deadline.IsZero() {
+		return invoke()
+	}
+	result := make(chan nucleo.Payload, 1)
+	go func() {
+		result <- invoke()
+	}()
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case value := <-result:
+		return value
+	case <-timer.C:
+		registry.logger.Warnln("Local action timed out - action: ", context.ActionName())
+		return timeoutError(context, registry.localNode.GetID())
+	}
+}
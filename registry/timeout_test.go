@@ -0,0 +1,120 @@
+package registry_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/errors"
+	"github.com/Bendomey/nucleo-go/transit/memory"
+)
+
+// remaining return the time left until the deadline of the call in milliseconds, -1 when it has no deadline.
+func remaining(context nucleo.Context) interface{} {
+	if context.Deadline().IsZero() {
+		return -1
+	}
+	return int(time.Until(context.Deadline()) / time.Millisecond)
+}
+
+func reportService(name string, settings map[string]interface{}) nucleo.ServiceSchema {
+	return nucleo.ServiceSchema{
+		Name: name,
+		Actions: []nucleo.Action{{
+			Name:     "report",
+			Settings: settings,
+			Handler: func(context nucleo.Context, params nucleo.Payload) interface{} {
+				return remaining(context)
+			},
+		}},
+	}
+}
+
+func expectRemaining(t *testing.T, name string, result nucleo.Payload, min, max int) {
+	t.Helper()
+	if result.IsError() {
+		t.Fatalf("%s: expected the remaining time, got error %s", name, result.Error())
+	}
+	if value := result.Int(); value < min || value > max {
+		t.Fatalf("%s: expected a remaining time between %dms and %dms, got %dms", name, min, max, value)
+	}
+}
+
+func TestTimeoutPrecedence(t *testing.T) {
+	bus := memory.NewBus()
+	config := nucleo.Config{RequestTimeout: 2 * time.Second}
+	startBroker(t, bus, "worker", config,
+		reportService("plain", nil),
+		reportService("timed", map[string]interface{}{"timeout": 500}),
+	)
+	client := startBroker(t, bus, "client", config,
+		reportService("local", nil),
+		reportService("localTimed", map[string]interface{}{"timeout": 500 * time.Millisecond}),
+	)
+	waitForNodes(t, client, "timed.report", 1)
+
+	expectRemaining(t, "remote call", <-client.Call("plain.report", nil), 1500, 2000)
+	expectRemaining(t, "remote call with action timeout", <-client.Call("timed.report", nil), 300, 500)
+	expectRemaining(t, "remote call with timeout option", <-client.Call("timed.report", nil, nucleo.Options{Timeout: 200 * time.Millisecond}), 50, 200)
+
+	// RequestTimeout only applies to remote calls
+	expectRemaining(t, "local call", <-client.Call("local.report", nil), -1, -1)
+	expectRemaining(t, "local call with action timeout", <-client.Call("localTimed.report", nil), 300, 500)
+	expectRemaining(t, "local call with timeout option", <-client.Call("localTimed.report", nil, nucleo.Options{Timeout: 200 * time.Millisecond}), 50, 200)
+}
+
+func TestDeadlinePropagation(t *testing.T) {
+	bus := memory.NewBus()
+	config := nucleo.Config{RequestTimeout: 2 * time.Second}
+	callChild := func(child string) nucleo.Action {
+		return nucleo.Action{
+			Name:     child,
+			Settings: map[string]interface{}{"timeout": 300},
+			Handler: func(context nucleo.Context, params nucleo.Payload) interface{} {
+				return (<-context.Call(child+".report", nil)).Value()
+			},
+		}
+	}
+	startBroker(t, bus, "worker", config,
+		nucleo.ServiceSchema{Name: "parent", Actions: []nucleo.Action{callChild("local"), callChild("remote")}},
+		reportService("local", map[string]interface{}{"timeout": 5000}),
+	)
+	client := startBroker(t, bus, "client", config,
+		reportService("remote", map[string]interface{}{"timeout": 5000}),
+	)
+	waitForNodes(t, client, "parent.remote", 1)
+	waitForNodes(t, client, "remote.report", 1)
+
+	// the child timeout of 5s does not extend the deadline of the parent
+	expectRemaining(t, "local child call", <-client.Call("parent.local", nil), 100, 300)
+	expectRemaining(t, "remote child call", <-client.Call("parent.remote", nil), 100, 300)
+}
+
+func TestLocalTimeoutExposesDeadline(t *testing.T) {
+	stopped := make(chan bool, 1)
+	client := startBroker(t, memory.NewBus(), "client", nucleo.Config{}, nucleo.ServiceSchema{
+		Name: "slow",
+		Actions: []nucleo.Action{{
+			Name:     "work",
+			Settings: map[string]interface{}{"timeout": 100},
+			Handler: func(context nucleo.Context, params nucleo.Payload) interface{} {
+				// a long running handler checking its deadline
+				for time.Now().Before(context.Deadline()) {
+					time.Sleep(5 * time.Millisecond)
+				}
+				stopped <- true
+				return "late"
+			},
+		}},
+	})
+
+	result := <-client.Call("slow.work", nil)
+	if _, isTimeout := result.Error().(*errors.RequestTimeoutError); !isTimeout {
+		t.Fatalf("expected a REQUEST_TIMEOUT, got %v", result.Value())
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("expected the handler to stop at its deadline")
+	}
+}
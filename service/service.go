@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/Bendomey/nucleo-go"
 	"github.com/Bendomey/nucleo-go/payload"
@@ -16,6 +17,7 @@ type Action struct {
 	fullname string
 	handler  nucleo.ActionHandler
 	params   nucleo.ActionParams
+	settings map[string]interface{}
 }
 
 type Event struct {
@@ -142,6 +144,14 @@ func (serviceAction *Action) Params() nucleo.Payload {
 	return serviceAction.params
 }
 
+func (serviceAction *Action) Settings() map[string]interface{} {
+	return serviceAction.settings
+}
+
+func (serviceAction *Action) SetSettings(settings map[string]interface{}) {
+	serviceAction.settings = settings
+}
+
 func (service *Service) Name() string {
 	return service.name
 }
@@ -346,10 +356,10 @@ func CreateServiceEvent(eventName, serviceName, group string, handler nucleo.Eve
 
 func CreateServiceAction(serviceName string, actionName string, handler nucleo.ActionHandler, params nucleo.ActionParams) Action {
 	return Action{
-		actionName,
-		fmt.Sprintf("%s.%s", serviceName, actionName),
-		handler,
-		params,
+		name:     actionName,
+		fullname: fmt.Sprintf("%s.%s", serviceName, actionName),
+		handler:  handler,
+		params:   params,
 	}
 }
 
@@ -376,6 +386,9 @@ func (service *Service) AsMap() map[string]interface{} {
 			actionInfo["name"] = serviceAction.fullname
 			actionInfo["rawName"] = serviceAction.name
 			actionInfo["params"] = paramsAsMap(serviceAction.params)
+			if settings := exportSettings(serviceAction.settings); len(settings) > 0 {
+				actionInfo["settings"] = settings
+			}
 			actions[serviceAction.fullname] = actionInfo
 		}
 	}
@@ -394,6 +407,43 @@ func (service *Service) AsMap() map[string]interface{} {
 	return serviceInfo
 }
 
+// exportSettings return the settings that can be sent to other nodes, values like functions are removed.
+// Durations are exported in milliseconds, the unit used by the nodes to read numeric durations (e.g. timeout, hedge).
+func exportSettings(settings map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	for key, value := range settings {
+		if value, exportable := exportSetting(value); exportable {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+func exportSetting(value interface{}) (interface{}, bool) {
+	if value == nil {
+		return nil, false
+	}
+	switch v := value.(type) {
+	case time.Duration:
+		return float64(v) / float64(time.Millisecond), true
+	case map[string]interface{}:
+		return exportSettings(v), true
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			if item, exportable := exportSetting(item); exportable {
+				result = append(result, item)
+			}
+		}
+		return result, true
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil, false
+	}
+	return value, true
+}
+
 func isInternalAction(action Action) bool {
 	return strings.Index(action.Name(), "$") == 0
 }
@@ -423,6 +473,9 @@ func (service *Service) AddActionMap(actionInfo map[string]interface{}) *Action
 		nil,
 		paramsFromMap(actionInfo["schema"]),
 	)
+	if settings, isMap := actionInfo["settings"].(map[string]interface{}); isMap {
+		action.settings = settings
+	}
 	service.actions = append(service.actions, action)
 	return &action
 }
@@ -528,6 +581,7 @@ func (service *Service) populateFromSchema() {
 			actionSchema.Handler,
 			paramsFromMap(actionSchema.Params),
 		)
+		service.actions[index].settings = actionSchema.Settings
 	}
 
 	service.events = make([]Event, len(schema.Events))
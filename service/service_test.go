@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Bendomey/nucleo-go/cache"
+	"github.com/Bendomey/nucleo-go/payload"
+	"github.com/Bendomey/nucleo-go/serializer"
+	log "github.com/sirupsen/logrus"
+)
+
+// the settings of the actions are sent to the other nodes in the INFO packet, where numeric durations are milliseconds.
+func TestExportSettingsDurationsRoundTrip(t *testing.T) {
+	settings := map[string]interface{}{
+		"timeout": 1500 * time.Millisecond,
+		"hedge": map[string]interface{}{
+			"delay":   200 * time.Millisecond,
+			"enabled": true,
+		},
+		"cache":    true,
+		"callback": func() {},
+	}
+
+	jsonSerializer := serializer.CreateJSONSerializer(log.WithField("serializer", "json"))
+	data := jsonSerializer.PayloadToBytes(payload.New(map[string]interface{}{"settings": exportSettings(settings)}))
+	received := jsonSerializer.BytesToPayload(&data).Get("settings")
+
+	if timeout := cache.ToDuration(received.Get("timeout").Value(), time.Millisecond); timeout != 1500*time.Millisecond {
+		t.Fatalf("expected timeout 1.5s, got %s", timeout)
+	}
+	if delay := cache.ToDuration(received.Get("hedge").Get("delay").Value(), time.Millisecond); delay != 200*time.Millisecond {
+		t.Fatalf("expected hedge delay 200ms, got %s", delay)
+	}
+	if !received.Get("hedge").Get("enabled").Bool() || !received.Get("cache").Bool() {
+		t.Fatal("expected the other settings to be kept")
+	}
+	if received.Get("callback").Exists() {
+		t.Fatal("expected functions to be removed")
+	}
+}
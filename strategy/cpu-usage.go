@@ -0,0 +1,52 @@
+package strategy
+
+import (
+	"math/rand"
+
+	"github.com/Bendomey/nucleo-go"
+)
+
+// CPUUsageStrategy selects the node with the lowest cpu usage among a random sample of the nodes.
+type CPUUsageStrategy struct {
+	// SampleCount number of nodes compared on each selection. Zero compares all nodes.
+	SampleCount int
+	// LowCPUUsage nodes with a cpu usage under this value are selected right away.
+	LowCPUUsage int64
+}
+
+func NewCPUUsageStrategy() Strategy {
+	return CPUUsageStrategy{SampleCount: 3, LowCPUUsage: 10}
+}
+
+func (cpuUsageStrategy CPUUsageStrategy) Select(context nucleo.BrokerContext, nodes []Selector) *Selector {
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	candidates := rand.Perm(len(nodes))
+	if cpuUsageStrategy.SampleCount > 0 && cpuUsageStrategy.SampleCount < len(candidates) {
+		candidates = candidates[:cpuUsageStrategy.SampleCount]
+	}
+
+	var selected *Selector
+	var lowest int64
+	for _, index := range candidates {
+		node := nodes[index].Node()
+		if node == nil {
+			continue
+		}
+		cpu := node.GetCPU()
+		if cpu < cpuUsageStrategy.LowCPUUsage {
+			return &nodes[index]
+		}
+		if selected == nil || cpu < lowest {
+			selected = &nodes[index]
+			lowest = cpu
+		}
+	}
+	if selected == nil {
+		// no cpu info available, fallback to a random node
+		return &nodes[candidates[0]]
+	}
+	return selected
+}
@@ -0,0 +1,54 @@
+package strategy
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+)
+
+// LatencyStrategy selects the node with the lowest average latency among a random sample of the nodes.
+// The latency of the nodes is measured by the latency collector (Config.Latency).
+type LatencyStrategy struct {
+	// SampleCount number of nodes compared on each selection. Zero compares all nodes.
+	SampleCount int
+	// LowLatency nodes with a latency under this value are selected right away.
+	LowLatency time.Duration
+}
+
+func NewLatencyStrategy() Strategy {
+	return LatencyStrategy{SampleCount: 5, LowLatency: 10 * time.Millisecond}
+}
+
+func (latencyStrategy LatencyStrategy) Select(context nucleo.BrokerContext, nodes []Selector) *Selector {
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	candidates := rand.Perm(len(nodes))
+	if latencyStrategy.SampleCount > 0 && latencyStrategy.SampleCount < len(candidates) {
+		candidates = candidates[:latencyStrategy.SampleCount]
+	}
+
+	var selected *Selector
+	var lowest time.Duration
+	for _, index := range candidates {
+		node := nodes[index].Node()
+		if node == nil || node.GetLatency() <= 0 {
+			continue
+		}
+		latency := node.GetLatency()
+		if latency < latencyStrategy.LowLatency {
+			return &nodes[index]
+		}
+		if selected == nil || latency < lowest {
+			selected = &nodes[index]
+			lowest = latency
+		}
+	}
+	if selected == nil {
+		// latency not measured yet, fallback to a random node
+		return &nodes[candidates[0]]
+	}
+	return selected
+}
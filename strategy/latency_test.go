@@ -0,0 +1,79 @@
+package strategy_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/registry"
+	"github.com/Bendomey/nucleo-go/strategy"
+	log "github.com/sirupsen/logrus"
+)
+
+type testSelector struct {
+	node nucleo.Node
+}
+
+func (selector testSelector) TargetNodeID() string {
+	return selector.node.GetID()
+}
+
+func (selector testSelector) Node() nucleo.Node {
+	return selector.node
+}
+
+// createSelectors create a selector for each node, with the latency of the node (zero when not measured).
+func createSelectors(latencies map[string]time.Duration) []strategy.Selector {
+	selectors := []strategy.Selector{}
+	for nodeID, latency := range latencies {
+		node := registry.CreateNode(nodeID, false, log.WithField("node", nodeID))
+		node.UpdateLatency(latency)
+		selectors = append(selectors, testSelector{node})
+	}
+	return selectors
+}
+
+func TestLatencySelectsFastestNode(t *testing.T) {
+	selectors := createSelectors(map[string]time.Duration{
+		"slow":       80 * time.Millisecond,
+		"fast":       20 * time.Millisecond,
+		"medium":     40 * time.Millisecond,
+		"unmeasured": 0,
+	})
+	latency := strategy.LatencyStrategy{LowLatency: 10 * time.Millisecond}
+	for index := 0; index < 20; index++ {
+		if selected := latency.Select(nil, selectors); (*selected).TargetNodeID() != "fast" {
+			t.Fatalf("expected the fastest node, got %s", (*selected).TargetNodeID())
+		}
+	}
+}
+
+func TestLatencySelectsLowLatencyNodeRightAway(t *testing.T) {
+	selectors := createSelectors(map[string]time.Duration{
+		"low":  5 * time.Millisecond,
+		"high": 80 * time.Millisecond,
+	})
+	latency := strategy.LatencyStrategy{LowLatency: 10 * time.Millisecond}
+	if selected := latency.Select(nil, selectors); (*selected).TargetNodeID() != "low" {
+		t.Fatalf("expected the low latency node, got %s", (*selected).TargetNodeID())
+	}
+}
+
+func TestLatencyFallbackWithoutMeasures(t *testing.T) {
+	selectors := createSelectors(map[string]time.Duration{"node-1": 0, "node-2": 0, "node-3": 0})
+	latency := strategy.NewLatencyStrategy()
+	selected := map[string]int{}
+	for index := 0; index < 100; index++ {
+		node := latency.Select(nil, selectors)
+		if node == nil {
+			t.Fatal("expected a node when no latency was measured")
+		}
+		selected[(*node).TargetNodeID()]++
+	}
+	if len(selected) < 2 {
+		t.Fatalf("expected random nodes when no latency was measured, got %v", selected)
+	}
+	if node := latency.Select(nil, nil); node != nil {
+		t.Fatalf("expected no node without endpoints, got %v", node)
+	}
+}
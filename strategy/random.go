@@ -2,13 +2,15 @@ package strategy
 
 import (
 	"math/rand"
+
+	"github.com/Bendomey/nucleo-go"
 )
 
 // RoundRobinStrategy exposes the type as a strategy option
 type RandomStrategy struct {
 }
 
-func (randomStrategy RandomStrategy) Select(nodes []Selector) *Selector {
+func (randomStrategy RandomStrategy) Select(context nucleo.BrokerContext, nodes []Selector) *Selector {
 	if len(nodes) == 0 {
 		return nil
 	}
@@ -1,5 +1,7 @@
 package strategy
 
+import "github.com/Bendomey/nucleo-go"
+
 // RoundRobinStrategy exposes the type as a strategy option
 type RoundRobinStrategy struct {
 	counter int
@@ -9,7 +11,7 @@ func NewRoundRobinStrategy() Strategy {
 	return &RoundRobinStrategy{counter: -1}
 }
 
-func (roundRobinStrategy *RoundRobinStrategy) Select(nodes []Selector) *Selector {
+func (roundRobinStrategy *RoundRobinStrategy) Select(context nucleo.BrokerContext, nodes []Selector) *Selector {
 	if len(nodes) == 0 {
 		return nil
 	}
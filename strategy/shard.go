@@ -0,0 +1,155 @@
+package strategy
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Bendomey/nucleo-go"
+)
+
+// maxCachedRings number of rings kept by the strategy, one for each distinct set of candidate nodes.
+const maxCachedRings = 64
+
+// ShardStrategy routes the calls with the same shard key to the same node using a consistent hash ring
+// over the candidate nodes. Calls without a shard key are sent to a random node.
+type ShardStrategy struct {
+	// Key path of the shard key. "params.userID" or "userID" read the params and "#meta.tenant" or "#tenant" read the meta.
+	Key string
+	// VirtualNodes number of points of each node in the ring.
+	VirtualNodes int
+
+	rings map[string]*hashRing
+	mutex *sync.Mutex
+}
+
+func NewShardStrategy(options nucleo.Shard) Strategy {
+	if options.VirtualNodes <= 0 {
+		options.VirtualNodes = nucleo.DefaultConfig.Shard.VirtualNodes
+	}
+	return &ShardStrategy{
+		Key:          options.Key,
+		VirtualNodes: options.VirtualNodes,
+		rings:        make(map[string]*hashRing),
+		mutex:        &sync.Mutex{},
+	}
+}
+
+func (shardStrategy *ShardStrategy) Select(context nucleo.BrokerContext, nodes []Selector) *Selector {
+	if len(nodes) == 0 {
+		return nil
+	}
+	key, exists := shardKey(context, shardStrategy.Key)
+	if !exists {
+		return &nodes[rand.Intn(len(nodes))]
+	}
+	nodeID := shardStrategy.ring(nodes).lookup(key)
+	for index := range nodes {
+		if nodes[index].TargetNodeID() == nodeID {
+			return &nodes[index]
+		}
+	}
+	return &nodes[rand.Intn(len(nodes))]
+}
+
+// Keyed the node is selected from the shard key of the call.
+func (shardStrategy *ShardStrategy) Keyed() bool {
+	return true
+}
+
+// ring return the hash ring of the nodes. Rings are cached by the set of node ids, so when nodes join or
+// leave the registry a new ring is built.
+func (shardStrategy *ShardStrategy) ring(nodes []Selector) *hashRing {
+	nodeIDs := make([]string, len(nodes))
+	for index, node := range nodes {
+		nodeIDs[index] = node.TargetNodeID()
+	}
+	sort.Strings(nodeIDs)
+	name := strings.Join(nodeIDs, ",")
+
+	shardStrategy.mutex.Lock()
+	defer shardStrategy.mutex.Unlock()
+	if ring, exists := shardStrategy.rings[name]; exists {
+		return ring
+	}
+	if len(shardStrategy.rings) >= maxCachedRings {
+		shardStrategy.rings = make(map[string]*hashRing)
+	}
+	ring := createHashRing(nodeIDs, shardStrategy.VirtualNodes)
+	shardStrategy.rings[name] = ring
+	return ring
+}
+
+// shardKey read the shard key from the params or from the meta (paths starting with #) of the context.
+func shardKey(context nucleo.BrokerContext, path string) (string, bool) {
+	if context == nil || path == "" {
+		return "", false
+	}
+	var value nucleo.Payload
+	if strings.HasPrefix(path, "#") {
+		path = strings.TrimPrefix(strings.TrimPrefix(path, "#"), "meta.")
+		value = context.Meta()
+	} else {
+		path = strings.TrimPrefix(path, "params.")
+		value = context.Payload()
+	}
+	if value == nil || !value.Exists() {
+		return "", false
+	}
+	value = value.Get(path)
+	if !value.Exists() {
+		return "", false
+	}
+	return value.String(), true
+}
+
+type ringPoint struct {
+	hash   uint32
+	nodeID string
+}
+
+// hashRing is a consistent hash ring with VirtualNodes points per node.
+type hashRing struct {
+	points []ringPoint
+}
+
+func createHashRing(nodeIDs []string, virtualNodes int) *hashRing {
+	points := make([]ringPoint, 0, len(nodeIDs)*virtualNodes)
+	for _, nodeID := range nodeIDs {
+		for index := 0; index < virtualNodes; index++ {
+			points = append(points, ringPoint{hash(nodeID + "#" + strconv.Itoa(index)), nodeID})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].hash == points[j].hash {
+			return points[i].nodeID < points[j].nodeID
+		}
+		return points[i].hash < points[j].hash
+	})
+	return &hashRing{points}
+}
+
+// lookup return the node owning the key: the first point clockwise from the hash of the key.
+func (ring *hashRing) lookup(key string) string {
+	if len(ring.points) == 0 {
+		return ""
+	}
+	keyHash := hash(key)
+	index := sort.Search(len(ring.points), func(i int) bool {
+		return ring.points[i].hash >= keyHash
+	})
+	if index == len(ring.points) {
+		index = 0
+	}
+	return ring.points[index].nodeID
+}
+
+// hash return the first 32 bits of the md5 of the value, which spreads similar keys (user1, user2, ...) across the ring.
+func hash(value string) uint32 {
+	sum := md5.Sum([]byte(value))
+	return binary.BigEndian.Uint32(sum[:4])
+}
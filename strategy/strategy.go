@@ -1,9 +1,26 @@
 package strategy
 
+import "github.com/Bendomey/nucleo-go"
+
 type Selector interface {
 	TargetNodeID() string
+	// Node return the info of the target node, nil when the node is not known.
+	Node() nucleo.Node
 }
 
 type Strategy interface {
-	Select([]Selector) *Selector
+	// Select return the node to be called. The context is the call or event being load balanced.
+	Select(nucleo.BrokerContext, []Selector) *Selector
+}
+
+// KeyedStrategy is implemented by the strategies that select the node from a key of the call, e.g. the shard strategy.
+// Calls with the same key must reach the same node, so the local endpoints are not preferred for these strategies.
+type KeyedStrategy interface {
+	Keyed() bool
+}
+
+// IsKeyed check if the strategy selects the node from a key of the call.
+func IsKeyed(stg Strategy) bool {
+	keyed, isKeyed := stg.(KeyedStrategy)
+	return isKeyed && keyed.Keyed()
 }
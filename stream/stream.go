@@ -0,0 +1,52 @@
+package stream
+
+import (
+	"io"
+)
+
+// IsStream check if the value is a stream: an io.Reader or a channel of chunks ([]byte).
+func IsStream(value interface{}) bool {
+	switch value.(type) {
+	case io.Reader, chan []byte, <-chan []byte:
+		return true
+	}
+	return false
+}
+
+// Reader return the stream value as an io.Reader. Channels of chunks are read until they are closed.
+// Action handlers should use it to read stream params and results, as a channel sent to a remote
+// node is received as an io.Reader.
+func Reader(value interface{}) (io.Reader, bool) {
+	switch source := value.(type) {
+	case io.Reader:
+		return source, true
+	case chan []byte:
+		return FromChannel(source), true
+	case <-chan []byte:
+		return FromChannel(source), true
+	}
+	return nil, false
+}
+
+// FromChannel create a reader returning the chunks received from the channel, until it is closed.
+func FromChannel(chunks <-chan []byte) io.Reader {
+	return &channelReader{chunks: chunks}
+}
+
+type channelReader struct {
+	chunks  <-chan []byte
+	current []byte
+}
+
+func (reader *channelReader) Read(buffer []byte) (int, error) {
+	for len(reader.current) == 0 {
+		chunk, open := <-reader.chunks
+		if !open {
+			return 0, io.EOF
+		}
+		reader.current = chunk
+	}
+	read := copy(buffer, reader.current)
+	reader.current = reader.current[read:]
+	return read, nil
+}
@@ -431,7 +431,7 @@ func (t *AmqpTransporter) getQueueOptions(command string, balancedQueue bool) (a
 
 		// If eventTimeToLive is specified, add to options.
 		if t.opts.EventTimeToLive != DurationNotDefined {
-			args["x-message-ttl"] = int(t.opts.AutoDeleteQueues / time.Millisecond)
+			args["x-message-ttl"] = int(t.opts.EventTimeToLive / time.Millisecond)
 		}
 
 	// Packet types meant for internal use
@@ -440,7 +440,7 @@ func (t *AmqpTransporter) getQueueOptions(command string, balancedQueue bool) (a
 
 		// If heartbeatTimeToLive is specified, add to options.
 		if t.opts.HeartbeatTimeToLive != DurationNotDefined {
-			args["x-message-ttl"] = int(t.opts.AutoDeleteQueues / time.Millisecond)
+			args["x-message-ttl"] = int(t.opts.HeartbeatTimeToLive / time.Millisecond)
 		}
 	case "DISCOVER", "DISCONNECT", "INFO", "PING", "PONG":
 		autoDelete = true
@@ -452,6 +452,8 @@ func (t *AmqpTransporter) getQueueOptions(command string, balancedQueue bool) (a
 			exclusive = value.(bool)
 		case "durable":
 			durable = value.(bool)
+		case "autoDelete":
+			autoDelete = value.(bool)
 		default:
 			args[key] = value
 		}
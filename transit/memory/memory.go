@@ -2,7 +2,9 @@ package memory
 
 import (
 	"fmt"
+	"math/rand"
 	"sync"
+	"time"
 
 	"github.com/Bendomey/nucleo-go"
 	"github.com/Bendomey/nucleo-go/serializer"
@@ -14,30 +16,103 @@ import (
 type Subscription struct {
 	id            string
 	transporterId string
+	nodeID        string
 	handler       transit.TransportHandler
 	active        bool
 }
 
+// SharedMemory is the bus of the memory transporters. Brokers created with the same bus
+// (e.g. passed in Config.TransporterOptions["bus"]) can talk to each other in the same process.
+// The bus can simulate latency, packet loss and network partitions for chaos tests.
 type SharedMemory struct {
 	handlers map[string][]Subscription
 	mutex    *sync.Mutex
+
+	minLatency time.Duration
+	maxLatency time.Duration
+	packetLoss float64
+	partitions map[string]int
+}
+
+// NewBus create a bus to be shared by the memory transporters of a cluster.
+func NewBus() *SharedMemory {
+	return &SharedMemory{handlers: make(map[string][]Subscription), mutex: &sync.Mutex{}}
+}
+
+func (memory *SharedMemory) init() {
+	if memory.handlers == nil {
+		memory.handlers = make(map[string][]Subscription)
+	}
+	if memory.mutex == nil {
+		memory.mutex = &sync.Mutex{}
+	}
+}
+
+// SetLatency delay the delivery of each packet by a random duration between min and max.
+func (memory *SharedMemory) SetLatency(min, max time.Duration) {
+	if max < min {
+		max = min
+	}
+	memory.mutex.Lock()
+	memory.minLatency = min
+	memory.maxLatency = max
+	memory.mutex.Unlock()
+}
+
+// SetPacketLoss drop packets with the given probability, from 0 (no loss) to 1 (all packets lost).
+func (memory *SharedMemory) SetPacketLoss(rate float64) {
+	memory.mutex.Lock()
+	memory.packetLoss = rate
+	memory.mutex.Unlock()
+}
+
+// Partition split the nodes in groups that can't reach each other. Nodes not listed in any group
+// can still reach all nodes. A new partition replaces the previous one.
+func (memory *SharedMemory) Partition(groups ...[]string) {
+	partitions := map[string]int{}
+	for index, group := range groups {
+		for _, nodeID := range group {
+			partitions[nodeID] = index
+		}
+	}
+	memory.mutex.Lock()
+	memory.partitions = partitions
+	memory.mutex.Unlock()
+}
+
+// Heal remove the partitions, so all nodes can reach each other again.
+func (memory *SharedMemory) Heal() {
+	memory.mutex.Lock()
+	memory.partitions = nil
+	memory.mutex.Unlock()
+}
+
+// reachable check if the packets of the sender are delivered to the target node. Must be called with the mutex locked.
+func (memory *SharedMemory) reachable(senderNodeID, targetNodeID string) bool {
+	senderGroup, senderExists := memory.partitions[senderNodeID]
+	targetGroup, targetExists := memory.partitions[targetNodeID]
+	return !senderExists || !targetExists || senderGroup == targetGroup
+}
+
+// delay return the simulated latency of a packet. Must be called with the mutex locked.
+func (memory *SharedMemory) delay() time.Duration {
+	if memory.maxLatency <= 0 {
+		return 0
+	}
+	return memory.minLatency + time.Duration(rand.Int63n(int64(memory.maxLatency-memory.minLatency)+1))
 }
 
 type MemoryTransporter struct {
 	prefix     string
 	instanceID string
+	nodeID     string
 	logger     *log.Entry
 	memory     *SharedMemory
 }
 
 func Create(logger *log.Entry, memory *SharedMemory) MemoryTransporter {
 	instanceID := utils.RandomString(5)
-	if memory.handlers == nil {
-		memory.handlers = make(map[string][]Subscription)
-	}
-	if memory.mutex == nil {
-		memory.mutex = &sync.Mutex{}
-	}
+	memory.init()
 	return MemoryTransporter{memory: memory, logger: logger, instanceID: instanceID}
 }
 
@@ -46,6 +121,7 @@ func (transporter *MemoryTransporter) SetPrefix(prefix string) {
 }
 
 func (transporter *MemoryTransporter) SetNodeID(nodeID string) {
+	transporter.nodeID = nodeID
 }
 
 func (transporter *MemoryTransporter) SetSerializer(serializer serializer.Serializer) {
@@ -66,6 +142,7 @@ func (transporter *MemoryTransporter) Disconnect() chan error {
 	endChan := make(chan error)
 	transporter.logger.Debugln("[Mem-Trans-", transporter.instanceID, "] -> Disconnecting() ...")
 
+	transporter.memory.mutex.Lock()
 	newHandlers := map[string][]Subscription{}
 	for key, subscriptions := range transporter.memory.handlers {
 		keep := []Subscription{}
@@ -77,6 +154,7 @@ func (transporter *MemoryTransporter) Disconnect() chan error {
 		newHandlers[key] = keep
 	}
 	transporter.memory.handlers = newHandlers
+	transporter.memory.mutex.Unlock()
 
 	go func() {
 		endChan <- nil
@@ -96,7 +174,7 @@ func (transporter *MemoryTransporter) Subscribe(command string, nodeID string, h
 	topic := topicName(transporter, command, nodeID)
 	transporter.logger.Traceln("[Mem-Trans-", transporter.instanceID, "] Subscribe() listen for command: ", command, " nodeID: ", nodeID, " topic: ", topic)
 
-	subscription := Subscription{utils.RandomString(5) + "_" + command, transporter.instanceID, handler, true}
+	subscription := Subscription{utils.RandomString(5) + "_" + command, transporter.instanceID, transporter.nodeID, handler, true}
 
 	transporter.memory.mutex.Lock()
 	_, exists := transporter.memory.handlers[topic]
@@ -113,13 +191,20 @@ func (transporter *MemoryTransporter) Publish(command, nodeID string, message nu
 	transporter.logger.Traceln("[Mem-Trans-", transporter.instanceID, "] Publish() command: ", command, " nodeID: ", nodeID, " message: \n", message, "\n - end")
 
 	transporter.memory.mutex.Lock()
-	subscriptions, exists := transporter.memory.handlers[topic]
-	transporter.memory.mutex.Unlock()
-	if exists {
-		for _, subscription := range subscriptions {
-			if subscription.active {
-				go subscription.handler(message)
-			}
+	defer transporter.memory.mutex.Unlock()
+	for _, subscription := range transporter.memory.handlers[topic] {
+		if !subscription.active || !transporter.memory.reachable(transporter.nodeID, subscription.nodeID) {
+			continue
 		}
+		if transporter.memory.packetLoss > 0 && rand.Float64() < transporter.memory.packetLoss {
+			transporter.logger.Traceln("[Mem-Trans-", transporter.instanceID, "] Publish() packet lost - topic: ", topic)
+			continue
+		}
+		go func(handler transit.TransportHandler, delay time.Duration) {
+			if delay > 0 {
+				time.Sleep(delay)
+			}
+			handler(message)
+		}(subscription.handler, transporter.memory.delay())
 	}
 }
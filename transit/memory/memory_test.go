@@ -0,0 +1,86 @@
+package memory_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/broker"
+	"github.com/Bendomey/nucleo-go/transit/memory"
+)
+
+func createBroker(bus *memory.SharedMemory, nodeID string) *broker.ServiceBroker {
+	return broker.New(&nucleo.Config{
+		LogLevel:           nucleo.LogLevelFatal,
+		DiscoverNodeID:     func() string { return nodeID },
+		TransporterOptions: map[string]interface{}{"bus": bus},
+		RequestTimeout:     300 * time.Millisecond,
+	})
+}
+
+func TestPartitionAndHeal(t *testing.T) {
+	bus := memory.NewBus()
+	worker := createBroker(bus, "worker")
+	worker.PublishServices(nucleo.ServiceSchema{
+		Name: "math",
+		Actions: []nucleo.Action{{
+			Name: "add",
+			Handler: func(context nucleo.Context, params nucleo.Payload) interface{} {
+				return params.Get("a").Int() + params.Get("b").Int()
+			},
+		}},
+	})
+	worker.Start()
+	defer worker.Stop()
+	client := createBroker(bus, "client")
+	client.Start()
+	defer client.Stop()
+	if err := client.WaitFor("math"); err != nil {
+		t.Fatal(err)
+	}
+	params := map[string]int{"a": 1, "b": 2}
+
+	if result := <-client.Call("math.add", params); result.IsError() || result.Int() != 3 {
+		t.Fatalf("expected 3 before the partition, got %v", result.Value())
+	}
+
+	bus.Partition([]string{"worker"}, []string{"client"})
+	if result := <-client.Call("math.add", params); !result.IsError() {
+		t.Fatalf("expected the call to fail during the partition, got %v", result.Value())
+	}
+
+	bus.Heal()
+	if result := <-client.Call("math.add", params); result.IsError() || result.Int() != 3 {
+		t.Fatalf("expected 3 after healing the partition, got %v", result.Value())
+	}
+}
+
+func TestLatency(t *testing.T) {
+	bus := memory.NewBus()
+	worker := createBroker(bus, "worker")
+	worker.PublishServices(nucleo.ServiceSchema{
+		Name: "echo",
+		Actions: []nucleo.Action{{
+			Name: "ping",
+			Handler: func(context nucleo.Context, params nucleo.Payload) interface{} {
+				return "pong"
+			},
+		}},
+	})
+	worker.Start()
+	defer worker.Stop()
+	client := createBroker(bus, "client")
+	client.Start()
+	defer client.Stop()
+	if err := client.WaitFor("echo"); err != nil {
+		t.Fatal(err)
+	}
+
+	bus.SetLatency(50*time.Millisecond, 60*time.Millisecond)
+	start := time.Now()
+	result := <-client.Call("echo.ping", nil)
+	// the request and the response are both delayed
+	if elapsed := time.Since(start); result.IsError() || elapsed < 100*time.Millisecond {
+		t.Fatalf("expected a delayed pong, got %v after %s", result.Value(), elapsed)
+	}
+}
@@ -0,0 +1,371 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/payload"
+	"github.com/Bendomey/nucleo-go/serializer"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+var testNodes int32
+
+func createTestTransporter(t *testing.T, url string) *MqttTransporter {
+	options, err := ParseOptions(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := log.WithField("transport", "mqtt-test")
+	options.Logger = logger
+	options.Serializer = serializer.CreateJSONSerializer(logger)
+	transporter := CreateMqttTransporter(options).(*MqttTransporter)
+	transporter.SetPrefix("MOL-test")
+	transporter.SetNodeID(fmt.Sprint("node-", atomic.AddInt32(&testNodes, 1)))
+	if err := <-transporter.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { <-transporter.Disconnect() })
+	return transporter
+}
+
+func receive(t *testing.T, received chan nucleo.Payload) nucleo.Payload {
+	select {
+	case message := <-received:
+		return message
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a message")
+	}
+	return nil
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	broker := startTestBroker(t)
+	subscriber := createTestTransporter(t, broker.url())
+	publisher := createTestTransporter(t, broker.url())
+
+	received := make(chan nucleo.Payload, 1)
+	subscriber.Subscribe("REQ", "node-1", func(message nucleo.Payload) {
+		received <- message
+	})
+	publisher.Publish("REQ", "node-1", payload.New(map[string]interface{}{"sender": "node-2", "action": "math.add"}))
+	if action := receive(t, received).Get("action").String(); action != "math.add" {
+		t.Fatalf("expected the REQ packet, got action %q", action)
+	}
+
+	publisher.Publish("REQ", "node-3", payload.New(map[string]interface{}{"sender": "node-2"}))
+	select {
+	case message := <-received:
+		t.Fatalf("expected packets of other nodes to be ignored, got %v", message)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSlowHandlerDoesNotBlockOthers(t *testing.T) {
+	broker := startTestBroker(t)
+	transporter := createTestTransporter(t, broker.url())
+	release := make(chan struct{})
+	defer close(release)
+	transporter.Subscribe("EVENT", "", func(message nucleo.Payload) {
+		<-release
+	})
+	received := make(chan nucleo.Payload, 1)
+	transporter.Subscribe("EVENT", "", func(message nucleo.Payload) {
+		received <- message
+	})
+
+	transporter.Publish("EVENT", "", payload.New(map[string]interface{}{"sender": "node-2", "event": "user.created"}))
+	if event := receive(t, received).Get("event").String(); event != "user.created" {
+		t.Fatalf("expected the EVENT packet, got event %q", event)
+	}
+}
+
+func TestTopicSeparator(t *testing.T) {
+	broker := startTestBroker(t)
+	transporter := createTestTransporter(t, broker.url())
+	received := make(chan nucleo.Payload, 1)
+	handler := func(message nucleo.Payload) {
+		received <- message
+	}
+
+	transporter.Subscribe("HEARTBEAT", "", handler)
+	transporter.Publish("HEARTBEAT", "", payload.New(map[string]interface{}{"sender": "node-1"}))
+	receive(t, received)
+	if topic := broker.lastTopic(); topic != "MOL-test.HEARTBEAT" {
+		t.Fatalf("expected the topics of the Moleculer MQTT transporter, got %q", topic)
+	}
+
+	custom := createTestTransporter(t, broker.url()+"?topicSeparator=/")
+	custom.Subscribe("REQ", "node-1", handler)
+	custom.Publish("REQ", "node-1", payload.New(map[string]interface{}{"sender": "node-2"}))
+	receive(t, received)
+	if topic := broker.lastTopic(); topic != "MOL-test/REQ/node-1" {
+		t.Fatalf("expected the custom separator, got %q", topic)
+	}
+}
+
+func TestCommandQoS(t *testing.T) {
+	broker := startTestBroker(t)
+	transporter := createTestTransporter(t, broker.url()+"?qos=1&qos.EVENT=0")
+	received := make(chan nucleo.Payload, 1)
+	handler := func(message nucleo.Payload) {
+		received <- message
+	}
+
+	expected := map[string]byte{"HEARTBEAT": 0, "REQ": 1, "EVENT": 0, "DISCOVER": 1}
+	for command := range expected {
+		transporter.Subscribe(command, "", handler)
+		transporter.Publish(command, "", payload.New(map[string]interface{}{"sender": "node-1"}))
+		receive(t, received)
+	}
+	for command, qos := range expected {
+		topic := "MOL-test." + command
+		if subscribed := broker.subscriptionQoS(topic); subscribed != qos {
+			t.Fatalf("expected %s to be subscribed with QoS %d, got %d", command, qos, subscribed)
+		}
+		if published := broker.publishQoS(topic); published != qos {
+			t.Fatalf("expected %s to be published with QoS %d, got %d", command, qos, published)
+		}
+	}
+}
+
+func TestSubscriptionNotConfirmed(t *testing.T) {
+	broker := startTestBroker(t)
+	broker.ignoreSubscriptions()
+	transporter := createTestTransporter(t, broker.url()+"?connectTimeout=200")
+	hook := test.NewLocal(transporter.logger.Logger)
+
+	transporter.Subscribe("REQ", "node-1", func(message nucleo.Payload) {})
+	entry := hook.LastEntry()
+	if entry == nil || entry.Level != log.ErrorLevel || !strings.Contains(entry.Message, "MOL-test.REQ.node-1") {
+		t.Fatalf("expected an error to be logged when the subscription is not confirmed, got %v", entry)
+	}
+}
+
+// testBroker is a MQTT 3.1.1 broker with the packets used by the transporter, it matches topics without wildcards.
+type testBroker struct {
+	listener net.Listener
+
+	mutex         sync.Mutex
+	clients       map[*testClient]bool
+	published     map[string]byte
+	subscriptions map[string]byte
+	last          string
+	ignoreSubs    bool
+}
+
+type testClient struct {
+	conn     net.Conn
+	mutex    sync.Mutex
+	topics   map[string]byte
+	packetID uint16
+}
+
+func startTestBroker(t *testing.T) *testBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	broker := &testBroker{
+		listener:      listener,
+		clients:       map[*testClient]bool{},
+		published:     map[string]byte{},
+		subscriptions: map[string]byte{},
+	}
+	go broker.accept()
+	t.Cleanup(func() { listener.Close() })
+	return broker
+}
+
+func (broker *testBroker) url() string {
+	return "mqtt://" + broker.listener.Addr().String()
+}
+
+func (broker *testBroker) lastTopic() string {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	return broker.last
+}
+
+func (broker *testBroker) publishQoS(topic string) byte {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	return broker.published[topic]
+}
+
+func (broker *testBroker) subscriptionQoS(topic string) byte {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	return broker.subscriptions[topic]
+}
+
+// ignoreSubscriptions stop sending SUBACK packets.
+func (broker *testBroker) ignoreSubscriptions() {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	broker.ignoreSubs = true
+}
+
+func (broker *testBroker) accept() {
+	for {
+		conn, err := broker.listener.Accept()
+		if err != nil {
+			return
+		}
+		client := &testClient{conn: conn, topics: map[string]byte{}}
+		broker.mutex.Lock()
+		broker.clients[client] = true
+		broker.mutex.Unlock()
+		go broker.serve(client)
+	}
+}
+
+func (broker *testBroker) serve(client *testClient) {
+	defer func() {
+		client.conn.Close()
+		broker.mutex.Lock()
+		delete(broker.clients, client)
+		broker.mutex.Unlock()
+	}()
+	reader := bufio.NewReader(client.conn)
+	for {
+		header, body, err := readPacket(reader)
+		if err != nil {
+			return
+		}
+		switch header >> 4 {
+		case 1: // CONNECT
+			client.write([]byte{0x20, 2, 0, 0})
+		case 3: // PUBLISH
+			broker.publish(client, (header>>1)&3, body)
+		case 8: // SUBSCRIBE
+			broker.subscribe(client, body)
+		case 10: // UNSUBSCRIBE
+			client.write([]byte{0xB0, 2, body[0], body[1]})
+		case 12: // PINGREQ
+			client.write([]byte{0xD0, 0})
+		case 14: // DISCONNECT
+			return
+		}
+	}
+}
+
+func readPacket(reader *bufio.Reader) (byte, []byte, error) {
+	header, err := reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for {
+		digit, err := reader.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(digit&127) * multiplier
+		multiplier *= 128
+		if digit&128 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	_, err = io.ReadFull(reader, body)
+	return header, body, err
+}
+
+func (broker *testBroker) publish(sender *testClient, qos byte, body []byte) {
+	length := int(binary.BigEndian.Uint16(body))
+	topic := string(body[2 : 2+length])
+	message := body[2+length:]
+	if qos > 0 {
+		sender.write([]byte{0x40, 2, message[0], message[1]})
+		message = message[2:]
+	}
+
+	broker.mutex.Lock()
+	broker.published[topic] = qos
+	broker.last = topic
+	receivers := map[*testClient]byte{}
+	for client := range broker.clients {
+		client.mutex.Lock()
+		if subscribed, exists := client.topics[topic]; exists {
+			receivers[client] = subscribed
+		}
+		client.mutex.Unlock()
+	}
+	broker.mutex.Unlock()
+
+	for client, subscribed := range receivers {
+		deliveryQoS := qos
+		if subscribed < deliveryQoS {
+			deliveryQoS = subscribed
+		}
+		variableHeader := append([]byte{byte(length >> 8), byte(length)}, topic...)
+		if deliveryQoS > 0 {
+			client.mutex.Lock()
+			client.packetID++
+			variableHeader = append(variableHeader, byte(client.packetID>>8), byte(client.packetID))
+			client.mutex.Unlock()
+		}
+		packet := append([]byte{0x30 | deliveryQoS<<1}, encodeLength(len(variableHeader)+len(message))...)
+		packet = append(packet, variableHeader...)
+		client.write(append(packet, message...))
+	}
+}
+
+func (broker *testBroker) subscribe(client *testClient, body []byte) {
+	packetID, topics := body[:2], body[2:]
+	granted := []byte{}
+	for len(topics) > 0 {
+		length := int(binary.BigEndian.Uint16(topics))
+		topic := string(topics[2 : 2+length])
+		qos := topics[2+length]
+		topics = topics[3+length:]
+		client.mutex.Lock()
+		client.topics[topic] = qos
+		client.mutex.Unlock()
+		broker.mutex.Lock()
+		broker.subscriptions[topic] = qos
+		broker.mutex.Unlock()
+		granted = append(granted, qos)
+	}
+	broker.mutex.Lock()
+	ignore := broker.ignoreSubs
+	broker.mutex.Unlock()
+	if ignore {
+		return
+	}
+	packet := append([]byte{0x90}, encodeLength(2+len(granted))...)
+	packet = append(packet, packetID...)
+	client.write(append(packet, granted...))
+}
+
+func (client *testClient) write(packet []byte) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	client.conn.Write(packet)
+}
+
+func encodeLength(length int) []byte {
+	encoded := []byte{}
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		encoded = append(encoded, digit)
+		if length == 0 {
+			return encoded
+		}
+	}
+}
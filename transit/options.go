@@ -0,0 +1,98 @@
+package transit
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// URLOptions parse the transporter url and merge its query with the transporter options, the query
+// taking precedence. Return the url without the query and the merged options.
+func URLOptions(rawUrl string, options map[string]interface{}) (*url.URL, map[string]interface{}, error) {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, nil, err
+	}
+	result := map[string]interface{}{}
+	for key, value := range options {
+		result[key] = value
+	}
+	query := parsed.Query()
+	for key := range query {
+		result[key] = query.Get(key)
+	}
+	parsed.RawQuery = ""
+	return parsed, result, nil
+}
+
+// ToBool convert a bool or a bool string (true, false, 1, 0...) option.
+func ToBool(value interface{}) (bool, bool) {
+	switch v := value.(type) {
+	case bool:
+		return v, true
+	case string:
+		result, err := strconv.ParseBool(v)
+		return result, err == nil
+	}
+	return false, false
+}
+
+// ToInt convert a number or a number string option.
+func ToInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case string:
+		result, err := strconv.Atoi(v)
+		return result, err == nil
+	}
+	return 0, false
+}
+
+// ToDuration convert a time.Duration, a duration string or a number of milliseconds option.
+func ToDuration(value interface{}) (time.Duration, bool) {
+	if duration, isDuration := value.(time.Duration); isDuration {
+		return duration, true
+	}
+	if text, isString := value.(string); isString {
+		if duration, err := time.ParseDuration(text); err == nil {
+			return duration, true
+		}
+	}
+	if milliseconds, isInt := ToInt(value); isInt {
+		return time.Duration(milliseconds) * time.Millisecond, true
+	}
+	return 0, false
+}
+
+// ToStringList convert a list or a comma separated string option.
+func ToStringList(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case string:
+		result := []string{}
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+		return result, true
+	case []string:
+		return v, true
+	case []interface{}:
+		result := make([]string, len(v))
+		for index, item := range v {
+			text, isString := item.(string)
+			if !isString {
+				return nil, false
+			}
+			result[index] = text
+		}
+		return result, true
+	}
+	return nil, false
+}
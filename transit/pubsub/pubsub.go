@@ -11,12 +11,18 @@ import (
 
 	"github.com/Bendomey/nucleo-go"
 	"github.com/Bendomey/nucleo-go/context"
+	nucleoErrors "github.com/Bendomey/nucleo-go/errors"
 	"github.com/Bendomey/nucleo-go/payload"
 	"github.com/Bendomey/nucleo-go/serializer"
+	"github.com/Bendomey/nucleo-go/stream"
 	"github.com/Bendomey/nucleo-go/transit"
+	"github.com/Bendomey/nucleo-go/transit/amqp"
 	"github.com/Bendomey/nucleo-go/transit/kafka"
 	"github.com/Bendomey/nucleo-go/transit/memory"
+	"github.com/Bendomey/nucleo-go/transit/mqtt"
 	"github.com/Bendomey/nucleo-go/transit/nats"
+	"github.com/Bendomey/nucleo-go/transit/redis"
+	"github.com/Bendomey/nucleo-go/transit/tcp"
 	"github.com/Bendomey/nucleo-go/utils"
 	"github.com/Bendomey/nucleo-go/version"
 	log "github.com/sirupsen/logrus"
@@ -30,6 +36,12 @@ type PubSub struct {
 	isConnected          bool
 	pendingRequests      map[string]pendingRequest
 	pendingRequestsMutex *sync.Mutex
+	pendingPings         map[string]chan nucleo.Payload
+	pendingPingsMutex    *sync.Mutex
+	outgoingStreams      map[string]*outgoingStream
+	incomingStreams      map[string]*incomingStream
+	finishedStreams      map[string]time.Time
+	streamsMutex         *sync.Mutex
 	serializer           serializer.Serializer
 
 	knownNeighbours   map[string]int64
@@ -83,6 +95,12 @@ func Create(broker *nucleo.BrokerDelegates) transit.Transit {
 		knownNeighbours:      knownNeighbours,
 		neighboursMutex:      &sync.Mutex{},
 		pendingRequestsMutex: &sync.Mutex{},
+		pendingPings:         make(map[string]chan nucleo.Payload),
+		pendingPingsMutex:    &sync.Mutex{},
+		outgoingStreams:      make(map[string]*outgoingStream),
+		incomingStreams:      make(map[string]*incomingStream),
+		finishedStreams:      make(map[string]time.Time),
+		streamsMutex:         &sync.Mutex{},
 	}
 
 	broker.Bus().On("$node.disconnected", transitImpl.onNodeDisconnected)
@@ -103,8 +121,20 @@ func (pubsub *PubSub) pendingRequestsByNode(nodeId string) []pendingRequest {
 	return list
 }
 
+// requestTimeout return the time left until the context deadline, or Config.RequestTimeout when the context has no deadline.
+func (pubsub *PubSub) requestTimeout(context nucleo.BrokerContext) time.Duration {
+	if deadline := context.Deadline(); !deadline.IsZero() {
+		return time.Until(deadline)
+	}
+	return pubsub.broker.Config.RequestTimeout
+}
+
 func (pubsub *PubSub) requestTimedOut(resultChan *chan nucleo.Payload, context nucleo.BrokerContext) func() {
-	pError := payload.New(errors.New("request timeout"))
+	timeoutError := nucleoErrors.NewRequestTimeoutError(nucleoErrors.NewRequestTimeoutErrorInput{
+		Action: context.ActionName(),
+		NodeID: context.TargetNodeID(),
+	})
+	pError := payload.New(&timeoutError)
 	return func() {
 		pubsub.logger.Debugln("requestTimedOut() nodeID: ", context.TargetNodeID())
 		pubsub.pendingRequestsMutex.Lock()
@@ -115,6 +145,7 @@ func (pubsub *PubSub) requestTimedOut(resultChan *chan nucleo.Payload, context n
 			(*p.resultChan) <- pError
 			p.timer.Stop()
 			delete(pubsub.pendingRequests, p.context.ID())
+			pubsub.cancelOutgoingStream(context.ID())
 		}
 	}
 }
@@ -135,6 +166,8 @@ func (pubsub *PubSub) onNodeDisconnected(values ...interface{}) {
 	}
 	pubsub.pendingRequestsMutex.Unlock()
 
+	pubsub.onStreamNodeDisconnected(nodeID)
+
 	pubsub.neighboursMutex.Lock()
 	delete(pubsub.knownNeighbours, nodeID)
 	pubsub.neighboursMutex.Unlock()
@@ -157,10 +190,31 @@ func isKafka(v string) bool {
 	return strings.Contains(v, "kafka://")
 }
 
-// CreateTransport : based on config it will load the transporter
-// for now is hard coded for NATS Streaming localhost
-func (pubsub *PubSub) createTransport() transit.Transport {
+func isAmqp(v string) bool {
+	return strings.HasPrefix(v, "amqp://") || strings.HasPrefix(v, "amqps://")
+}
+
+func isTcp(v string) bool {
+	return strings.HasPrefix(v, "tcp://")
+}
+
+func isRedis(v string) bool {
+	return strings.HasPrefix(v, "redis://") || strings.HasPrefix(v, "rediss://")
+}
+
+func isMqtt(v string) bool {
+	return strings.HasPrefix(v, "mqtt://") || strings.HasPrefix(v, "mqtts://")
+}
+
+func isMemory(v string) bool {
+	return v == "" || strings.ToUpper(v) == "MEMORY"
+}
+
+// CreateTransport : based on config it will load the transporter.
+// Return an error when the transporter url is not supported.
+func (pubsub *PubSub) createTransport() (transit.Transport, error) {
 	var transport transit.Transport
+	var err error
 	if pubsub.broker.Config.TransporterFactory != nil {
 		pubsub.logger.Infoln("Transporter: Custom factory")
 		transport = pubsub.broker.Config.TransporterFactory().(transit.Transport)
@@ -173,14 +227,31 @@ func (pubsub *PubSub) createTransport() transit.Transport {
 	} else if isKafka(pubsub.broker.Config.Transporter) {
 		pubsub.logger.Infoln("Transporter: KafkaTransporter")
 		transport = pubsub.createKafkaTransporter()
-	} else {
+	} else if isAmqp(pubsub.broker.Config.Transporter) {
+		pubsub.logger.Infoln("Transporter: AmqpTransporter")
+		transport, err = pubsub.createAmqpTransporter()
+	} else if isTcp(pubsub.broker.Config.Transporter) {
+		pubsub.logger.Infoln("Transporter: TcpTransporter")
+		transport, err = pubsub.createTcpTransporter()
+	} else if isRedis(pubsub.broker.Config.Transporter) {
+		pubsub.logger.Infoln("Transporter: RedisTransporter")
+		transport = pubsub.createRedisTransporter()
+	} else if isMqtt(pubsub.broker.Config.Transporter) {
+		pubsub.logger.Infoln("Transporter: MqttTransporter")
+		transport, err = pubsub.createMqttTransporter()
+	} else if isMemory(pubsub.broker.Config.Transporter) {
 		pubsub.logger.Infoln("Transporter: Memory")
 		transport = pubsub.createMemoryTransporter()
+	} else {
+		err = errors.New(fmt.Sprint("Unsupported transporter: ", pubsub.broker.Config.Transporter))
+	}
+	if err != nil {
+		return nil, err
 	}
 	transport.SetPrefix(resolveNamespace(pubsub.broker.Config.Namespace))
 	transport.SetNodeID(pubsub.broker.LocalNode().GetID())
 	transport.SetSerializer(pubsub.serializer)
-	return transport
+	return transport, nil
 }
 
 func resolveNamespace(namespace string) string {
@@ -193,7 +264,12 @@ func resolveNamespace(namespace string) string {
 func (pubsub *PubSub) createMemoryTransporter() transit.Transport {
 	pubsub.logger.Debugln("createMemoryTransporter() ... ")
 	logger := pubsub.logger.WithField("transport", "memory")
-	mem := memory.Create(logger, &memory.SharedMemory{})
+	// brokers share the bus passed in the transporter options, otherwise each broker has its own bus.
+	bus, isBus := pubsub.broker.Config.TransporterOptions["bus"].(*memory.SharedMemory)
+	if !isBus {
+		bus = memory.NewBus()
+	}
+	mem := memory.Create(logger, bus)
 	return &mem
 }
 
@@ -208,6 +284,52 @@ func (pubsub *PubSub) createKafkaTransporter() transit.Transport {
 	})
 }
 
+func (pubsub *PubSub) createAmqpTransporter() (transit.Transport, error) {
+	pubsub.logger.Debugln("createAmqpTransporter()")
+
+	options, err := amqp.ParseOptions(pubsub.broker.Config.Transporter, pubsub.broker.Config.TransporterOptions)
+	if err != nil {
+		return nil, err
+	}
+	options.Logger = pubsub.logger.WithField("transport", "amqp")
+	options.Serializer = pubsub.serializer
+	return amqp.CreateAmqpTransporter(options), nil
+}
+
+func (pubsub *PubSub) createTcpTransporter() (transit.Transport, error) {
+	pubsub.logger.Debugln("createTcpTransporter()")
+
+	options, err := tcp.ParseOptions(pubsub.broker.Config.Transporter, pubsub.broker.Config.TransporterOptions)
+	if err != nil {
+		return nil, err
+	}
+	options.Logger = pubsub.logger.WithField("transport", "tcp")
+	options.Serializer = pubsub.serializer
+	return tcp.CreateTcpTransporter(options), nil
+}
+
+func (pubsub *PubSub) createRedisTransporter() transit.Transport {
+	pubsub.logger.Debugln("createRedisTransporter()")
+
+	return redis.CreateRedisTransporter(redis.RedisOptions{
+		URL:        pubsub.broker.Config.Transporter,
+		Logger:     pubsub.logger.WithField("transport", "redis"),
+		Serializer: pubsub.serializer,
+	})
+}
+
+func (pubsub *PubSub) createMqttTransporter() (transit.Transport, error) {
+	pubsub.logger.Debugln("createMqttTransporter()")
+
+	options, err := mqtt.ParseOptions(pubsub.broker.Config.Transporter, pubsub.broker.Config.TransporterOptions)
+	if err != nil {
+		return nil, err
+	}
+	options.Logger = pubsub.logger.WithField("transport", "mqtt")
+	options.Serializer = pubsub.serializer
+	return mqtt.CreateMqttTransporter(options), nil
+}
+
 func (pubsub *PubSub) createNatsTransporter() transit.Transport {
 	pubsub.logger.Debugln("createNatsTransporter()")
 
@@ -342,7 +464,8 @@ func (pubsub *PubSub) Emit(context nucleo.BrokerContext) {
 func (pubsub *PubSub) Request(context nucleo.BrokerContext) chan nucleo.Payload {
 	pubsub.checkMaxQueueSize()
 
-	resultChan := make(chan nucleo.Payload)
+	// buffered, so the response, timeout or cancellation is delivered even when the caller stopped waiting
+	resultChan := make(chan nucleo.Payload, 1)
 
 	targetNodeID := context.TargetNodeID()
 	payload := context.AsMap()
@@ -353,6 +476,10 @@ func (pubsub *PubSub) Request(context nucleo.BrokerContext) chan nucleo.Payload
 	} else {
 		payload["paramsType"] = DATATYPE_NULL
 	}
+	source, isStream := stream.Reader(context.Payload().Value())
+	if isStream {
+		payload["params"] = nil
+	}
 
 	pubsub.logger.Traceln("Request() targetNodeID: ", targetNodeID, " payload: ", payload)
 
@@ -369,15 +496,42 @@ func (pubsub *PubSub) Request(context nucleo.BrokerContext) chan nucleo.Payload
 		&resultChan,
 
 		time.AfterFunc(
-			pubsub.broker.Config.RequestTimeout,
+			pubsub.requestTimeout(context),
 			pubsub.requestTimedOut(&resultChan, context)),
 	}
 	pubsub.pendingRequestsMutex.Unlock()
 
+	if isStream {
+		go pubsub.sendStream("REQ", targetNodeID, context.ID(), source, func(seq int, chunk []byte, err error) map[string]interface{} {
+			values := streamPacket(payload, "params", "paramsType", seq, chunk)
+			if err != nil {
+				values["error"] = map[string]interface{}{"message": err.Error(), "name": "Error"}
+			}
+			return values
+		})
+		return resultChan
+	}
 	pubsub.transport.Publish("REQ", targetNodeID, message)
 	return resultChan
 }
 
+// CancelRequest discard the pending request of the context, e.g. the slower request of a hedged call.
+// The response of the remote node is ignored when it arrives.
+func (pubsub *PubSub) CancelRequest(context nucleo.BrokerContext) {
+	pubsub.pendingRequestsMutex.Lock()
+	defer pubsub.pendingRequestsMutex.Unlock()
+
+	p, exists := pubsub.pendingRequests[context.ID()]
+	if !exists {
+		return
+	}
+	pubsub.logger.Debugln("CancelRequest() id: ", context.ID(), " targetNodeID: ", context.TargetNodeID())
+	p.timer.Stop()
+	delete(pubsub.pendingRequests, context.ID())
+	pubsub.cancelOutgoingStream(context.ID())
+	(*p.resultChan) <- payload.New(fmt.Errorf("Request %s to node %s was canceled.", context.ID(), context.TargetNodeID()))
+}
+
 // validateVersion check that version of the message is correct.
 func (pubsub *PubSub) validate(handler func(message nucleo.Payload)) transit.TransportHandler {
 	return func(msg nucleo.Payload) {
@@ -410,6 +564,10 @@ func (pubsub *PubSub) validateVersion(msg nucleo.Payload) bool {
 // reponseHandler responsible for whem a reponse arrives form a remote node.
 func (pubsub *PubSub) reponseHandler() transit.TransportHandler {
 	return func(message nucleo.Payload) {
+		if message.Get("seq").Exists() {
+			pubsub.responseStreamHandler(message)
+			return
+		}
 		pubsub.pendingRequestsMutex.Lock()
 		defer pubsub.pendingRequestsMutex.Unlock()
 
@@ -458,6 +616,14 @@ func (pubsub *PubSub) nucleoJSError(message nucleo.Payload) error {
 	if message.Get("error").Get("stack").Exists() {
 		pubsub.logger.Errorln(message.Get("error").Get("stack").Value())
 	}
+	if message.Get("error").Get("retryable").Bool() {
+		retryableError := nucleoErrors.NewNucleoRetryableError(nucleoErrors.NewNucleoRetryableErrorInput{
+			Message: &msg,
+			Type:    message.Get("error").Get("type").String(),
+			Data:    message.Get("error").Get("data").Value(),
+		})
+		return &retryableError
+	}
 	return errors.New(msg)
 }
 
@@ -482,21 +648,35 @@ func (pubsub *PubSub) sendResponse(context nucleo.BrokerContext, response nucleo
 		values["dataType"] = DATATYPE_NULL
 	}
 
+	if source, isStream := stream.Reader(response.Value()); isStream && !response.IsError() {
+		values["success"] = true
+		go pubsub.sendStream("RES", targetNodeID, context.ID(), source, func(seq int, chunk []byte, err error) map[string]interface{} {
+			packet := streamPacket(values, "data", "dataType", seq, chunk)
+			if err != nil {
+				packet["success"] = false
+				packet["error"] = map[string]interface{}{"message": err.Error(), "name": "Error"}
+			}
+			return packet
+		})
+		return
+	}
+
 	if response.IsError() {
-		var errMap map[string]string
+		var errMap map[string]interface{}
 		actionError, isActionError := response.Value().(ActionError)
 		if isActionError {
-			errMap = map[string]string{
+			errMap = map[string]interface{}{
 				"message": actionError.Error(),
 				"stack":   actionError.Stack(),
 				"name":    "Error",
 			}
 		} else {
-			errMap = map[string]string{
+			errMap = map[string]interface{}{
 				"message": response.String(),
 				"name":    "Error",
 			}
 		}
+		errMap["retryable"] = nucleoErrors.IsRetryable(response.Error())
 		values["success"] = false
 		values["error"] = errMap
 	} else {
@@ -533,6 +713,10 @@ func parseParamsType(value nucleo.Payload) string {
 // 3: send a response
 func (pubsub *PubSub) requestHandler() transit.TransportHandler {
 	return func(message nucleo.Payload) {
+		if message.Get("seq").Exists() {
+			pubsub.requestStreamHandler(message)
+			return
+		}
 		paramsType := parseParamsType(message.Get("paramsType"))
 		if paramsType != "1" && paramsType != "2" {
 			errMsg := "Expecting paramsType == 2 (JSON) or 1 (Null) - received: " + paramsType
@@ -621,27 +805,59 @@ func (pubsub *PubSub) emitRegistryEvent(command string) transit.TransportHandler
 	}
 }
 
-func (pubsub *PubSub) SendPing() {
+// SendPing send a PING to the node and return the id of the ping. Times are sent in milliseconds.
+func (pubsub *PubSub) SendPing(nodeID string) string {
+	id := utils.RandomString(12)
 	ping := make(map[string]interface{})
-	sender := pubsub.broker.LocalNode().GetID()
-	ping["sender"] = sender
+	ping["sender"] = pubsub.broker.LocalNode().GetID()
 	ping["ver"] = version.NucleoProtocol()
-	ping["time"] = time.Now().Unix()
-	ping["id"] = utils.RandomString(12)
-	pingMessage, _ := pubsub.serializer.MapToPayload(&ping)
-	pubsub.transport.Publish("PING", sender, pingMessage)
+	ping["time"] = time.Now().UnixMilli()
+	ping["id"] = id
+	pingMessage, err := pubsub.serializer.MapToPayload(&ping)
+	if err != nil {
+		pubsub.logger.Errorln("SendPing() Error serializing the ping: ", ping, " error: ", err)
+		return id
+	}
+	pubsub.transport.Publish("PING", nodeID, pingMessage)
+	return id
+}
+
+// Ping send a PING to the node and wait for the PONG. The result contains the round trip (elapsedTime)
+// and the clock difference between the nodes (timeDiff) in milliseconds.
+func (pubsub *PubSub) Ping(nodeID string, timeout time.Duration) chan nucleo.Payload {
+	result := make(chan nucleo.Payload, 1)
+	pong := make(chan nucleo.Payload, 1)
 
+	pubsub.pendingPingsMutex.Lock()
+	id := pubsub.SendPing(nodeID)
+	pubsub.pendingPings[id] = pong
+	pubsub.pendingPingsMutex.Unlock()
+
+	go func() {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case value := <-pong:
+			result <- value
+		case <-timer.C:
+			pubsub.pendingPingsMutex.Lock()
+			delete(pubsub.pendingPings, id)
+			pubsub.pendingPingsMutex.Unlock()
+			result <- payload.New(fmt.Errorf("Ping to node %s timed out after %s.", nodeID, timeout))
+		}
+	}()
+	return result
 }
 
 func (pubsub *PubSub) pingHandler() transit.TransportHandler {
 	return func(message nucleo.Payload) {
 		pong := make(map[string]interface{})
 		sender := message.Get("sender").String()
-		pong["sender"] = sender
+		pong["sender"] = pubsub.broker.LocalNode().GetID()
 		pong["ver"] = version.NucleoProtocol()
-		pong["time"] = message.Get("time").Int()
-		pong["arrived"] = time.Now().Unix()
-		pong["id"] = utils.RandomString(12)
+		pong["time"] = message.Get("time").Int64()
+		pong["arrived"] = time.Now().UnixMilli()
+		pong["id"] = message.Get("id").String()
 
 		pongMessage, _ := pubsub.serializer.MapToPayload(&pong)
 		pubsub.transport.Publish("PONG", sender, pongMessage)
@@ -650,11 +866,11 @@ func (pubsub *PubSub) pingHandler() transit.TransportHandler {
 
 func (pubsub *PubSub) pongHandler() transit.TransportHandler {
 	return func(message nucleo.Payload) {
-		now := time.Now().Unix()
+		now := time.Now().UnixMilli()
 		elapsed := now - message.Get("time").Int64()
 		arrived := message.Get("arrived").Int64()
-		timeDiff := math.Round(
-			float64(now) - float64(arrived) - float64(elapsed)/2)
+		timeDiff := int64(math.Round(
+			float64(now) - float64(arrived) - float64(elapsed)/2))
 
 		mapValue := make(map[string]interface{})
 		mapValue["nodeID"] = message.Get("sender").String()
@@ -662,6 +878,14 @@ func (pubsub *PubSub) pongHandler() transit.TransportHandler {
 		mapValue["timeDiff"] = timeDiff
 		mapValue["id"] = message.Get("id").String()
 
+		pubsub.pendingPingsMutex.Lock()
+		pending, exists := pubsub.pendingPings[mapValue["id"].(string)]
+		delete(pubsub.pendingPings, mapValue["id"].(string))
+		pubsub.pendingPingsMutex.Unlock()
+		if exists {
+			pending <- payload.New(mapValue)
+		}
+
 		pubsub.broker.Bus().EmitAsync("$node.pong", []interface{}{mapValue})
 	}
 }
@@ -682,6 +906,7 @@ func (pubsub *PubSub) subscribe() {
 	pubsub.transport.Subscribe("DISCOVER", "", pubsub.validate(pubsub.discoverHandler()))
 	pubsub.transport.Subscribe("PING", nodeID, pubsub.validate(pubsub.pingHandler()))
 	pubsub.transport.Subscribe("PONG", nodeID, pubsub.validate(pubsub.pongHandler()))
+	pubsub.transport.Subscribe("STREAM", nodeID, pubsub.validate(pubsub.streamHandler()))
 
 }
 
@@ -715,7 +940,15 @@ func (pubsub *PubSub) Connect() chan error {
 		return endChan
 	}
 	pubsub.logger.Debugln("PubSub - Connecting transport...")
-	pubsub.transport = pubsub.createTransport()
+	transport, err := pubsub.createTransport()
+	if err != nil {
+		pubsub.logger.Errorln("PubSub - Error creating transport - error: ", err)
+		go func() {
+			endChan <- err
+		}()
+		return endChan
+	}
+	pubsub.transport = transport
 	go func() {
 		err := <-pubsub.transport.Connect()
 		if err == nil {
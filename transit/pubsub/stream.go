@@ -0,0 +1,405 @@
+package pubsub
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/context"
+	"github.com/Bendomey/nucleo-go/payload"
+	"github.com/Bendomey/nucleo-go/version"
+)
+
+// streamChunkSize maximum size of the chunk sent in each stream packet.
+const streamChunkSize = 64 * 1024
+
+// streamWindow number of chunks sent without an acknowledgement from the receiver, so a slow
+// consumer limits the memory used by the chunks waiting to be read.
+const streamWindow = 16
+
+// outgoingStream is a stream sent by this node, as the params of a request or as a response.
+// The receiver acknowledges each chunk read and cancels the stream when it stops reading.
+type outgoingStream struct {
+	targetNodeID string
+	credits      chan struct{}
+	canceled     chan struct{}
+	cancelOnce   *sync.Once
+}
+
+func createOutgoingStream(targetNodeID string) *outgoingStream {
+	credits := make(chan struct{}, streamWindow)
+	for i := 0; i < streamWindow; i++ {
+		credits <- struct{}{}
+	}
+	return &outgoingStream{targetNodeID, credits, make(chan struct{}), &sync.Once{}}
+}
+
+func (outgoing *outgoingStream) ack() {
+	select {
+	case outgoing.credits <- struct{}{}:
+	default:
+	}
+}
+
+func (outgoing *outgoingStream) cancel() {
+	outgoing.cancelOnce.Do(func() {
+		close(outgoing.canceled)
+	})
+}
+
+// streamPacketFunc create the packet of a chunk. The last packet has no chunk and carries the error
+// when the source could not be read.
+type streamPacketFunc func(seq int, chunk []byte, err error) map[string]interface{}
+
+// sendStream read the source and publish its chunks as ordered packets (seq) to the target node.
+// The last packet has stream false. Sending stops when the receiver cancels the stream or does not
+// acknowledge the chunks within the request timeout.
+func (pubsub *PubSub) sendStream(command, targetNodeID, id string, source io.Reader, packet streamPacketFunc) {
+	outgoing := createOutgoingStream(targetNodeID)
+	pubsub.streamsMutex.Lock()
+	pubsub.outgoingStreams[id] = outgoing
+	pubsub.streamsMutex.Unlock()
+	defer func() {
+		pubsub.streamsMutex.Lock()
+		delete(pubsub.outgoingStreams, id)
+		pubsub.streamsMutex.Unlock()
+		if closer, isCloser := source.(io.Closer); isCloser {
+			closer.Close()
+		}
+	}()
+
+	idleTimeout := pubsub.broker.Config.RequestTimeout
+	buffer := make([]byte, streamChunkSize)
+	seq := 0
+	for {
+		read, err := source.Read(buffer)
+		if read > 0 {
+			timer := time.NewTimer(idleTimeout)
+			select {
+			case <-outgoing.credits:
+				timer.Stop()
+			case <-outgoing.canceled:
+				timer.Stop()
+				pubsub.logger.Debugln("sendStream() stream canceled by the receiver - id: ", id, " targetNodeID: ", targetNodeID)
+				return
+			case <-timer.C:
+				pubsub.logger.Warnln("sendStream() receiver did not read the stream for ", idleTimeout, " - id: ", id, " targetNodeID: ", targetNodeID)
+				pubsub.publishStreamPacket(command, targetNodeID, packet(seq, nil, fmt.Errorf("Stream %s timed out waiting for the receiver.", id)))
+				return
+			}
+			chunk := make([]byte, read)
+			copy(chunk, buffer[:read])
+			pubsub.publishStreamPacket(command, targetNodeID, packet(seq, chunk, nil))
+			seq++
+		}
+		if err == io.EOF {
+			pubsub.publishStreamPacket(command, targetNodeID, packet(seq, nil, nil))
+			return
+		}
+		if err != nil {
+			pubsub.logger.Errorln("sendStream() error reading the stream - id: ", id, " error: ", err)
+			pubsub.publishStreamPacket(command, targetNodeID, packet(seq, nil, err))
+			return
+		}
+		select {
+		case <-outgoing.canceled:
+			pubsub.logger.Debugln("sendStream() stream canceled by the receiver - id: ", id, " targetNodeID: ", targetNodeID)
+			return
+		default:
+		}
+	}
+}
+
+func (pubsub *PubSub) publishStreamPacket(command, targetNodeID string, values map[string]interface{}) {
+	message, err := pubsub.serializer.MapToPayload(&values)
+	if err != nil {
+		pubsub.logger.Errorln("publishStreamPacket() Error serializing the packet: ", values, " error: ", err)
+		return
+	}
+	pubsub.transport.Publish(command, targetNodeID, message)
+}
+
+// streamPacket copy the base packet and add the stream fields. The chunk is encoded in base64 in the data field.
+func streamPacket(base map[string]interface{}, dataField, typeField string, seq int, chunk []byte) map[string]interface{} {
+	values := make(map[string]interface{}, len(base)+3)
+	for key, value := range base {
+		values[key] = value
+	}
+	values["seq"] = seq
+	values["stream"] = chunk != nil
+	values[typeField] = DATATYPE_BUFFER
+	values[dataField] = nil
+	if chunk != nil {
+		values[dataField] = base64.StdEncoding.EncodeToString(chunk)
+	}
+	return values
+}
+
+// cancelOutgoingStream stop sending the stream with the id, e.g. when the request timed out.
+func (pubsub *PubSub) cancelOutgoingStream(id string) {
+	pubsub.streamsMutex.Lock()
+	outgoing, exists := pubsub.outgoingStreams[id]
+	pubsub.streamsMutex.Unlock()
+	if exists {
+		outgoing.cancel()
+	}
+}
+
+// streamHandler handles the acknowledgements and cancellations of the streams sent by this node.
+func (pubsub *PubSub) streamHandler() func(message nucleo.Payload) {
+	return func(message nucleo.Payload) {
+		id := message.Get("id").String()
+		pubsub.streamsMutex.Lock()
+		outgoing, exists := pubsub.outgoingStreams[id]
+		pubsub.streamsMutex.Unlock()
+		if !exists {
+			return
+		}
+		if message.Get("cancel").Bool() {
+			outgoing.cancel()
+		} else {
+			outgoing.ack()
+		}
+	}
+}
+
+// incomingStream is a stream received by this node. It reorders the chunks by seq and implements
+// io.ReadCloser. Closing the stream before the end cancels it in the sender node.
+type incomingStream struct {
+	pubsub   *PubSub
+	id       string
+	sender   string
+	chunks   map[int][]byte
+	next     int
+	end      int
+	err      error
+	closed   bool
+	current  []byte
+	mutex    *sync.Mutex
+	received *sync.Cond
+}
+
+// streamTombstoneFactor is how many RequestTimeout the ids of the finished streams are kept. The sender stops
+// within RequestTimeout after a cancellation, until then its late packets must not start the stream again.
+const streamTombstoneFactor = 3
+
+// incomingStream return the stream with the id received from the sender, created on the first packet.
+// Return nil when the stream already finished.
+func (pubsub *PubSub) incomingStream(id, sender string) (*incomingStream, bool) {
+	pubsub.streamsMutex.Lock()
+	defer pubsub.streamsMutex.Unlock()
+	if incoming, exists := pubsub.incomingStreams[id]; exists {
+		return incoming, false
+	}
+	if _, finished := pubsub.finishedStreams[id]; finished {
+		return nil, false
+	}
+	mutex := &sync.Mutex{}
+	incoming := &incomingStream{
+		pubsub:   pubsub,
+		id:       id,
+		sender:   sender,
+		chunks:   make(map[int][]byte),
+		end:      -1,
+		mutex:    mutex,
+		received: sync.NewCond(mutex),
+	}
+	pubsub.incomingStreams[id] = incoming
+	return incoming, true
+}
+
+// removeIncomingStream forget the stream and keep its id, so the late packets of the stream are ignored.
+func (pubsub *PubSub) removeIncomingStream(id string) {
+	now := time.Now()
+	pubsub.streamsMutex.Lock()
+	defer pubsub.streamsMutex.Unlock()
+	if _, exists := pubsub.incomingStreams[id]; !exists {
+		return
+	}
+	delete(pubsub.incomingStreams, id)
+	for finishedID, finished := range pubsub.finishedStreams {
+		if now.Sub(finished) > streamTombstoneFactor*pubsub.broker.Config.RequestTimeout {
+			delete(pubsub.finishedStreams, finishedID)
+		}
+	}
+	pubsub.finishedStreams[id] = now
+}
+
+// push add the chunk of the packet to the stream.
+func (incoming *incomingStream) push(message nucleo.Payload, dataField string) {
+	seq := message.Get("seq").Int()
+	incoming.mutex.Lock()
+	defer incoming.mutex.Unlock()
+	if incoming.closed {
+		return
+	}
+	if message.Get("stream").Bool() {
+		chunk, err := base64.StdEncoding.DecodeString(message.Get(dataField).String())
+		if err != nil {
+			incoming.fail(fmt.Errorf("Invalid chunk %d of stream %s: %s", seq, incoming.id, err))
+			return
+		}
+		incoming.chunks[seq] = chunk
+	} else {
+		incoming.end = seq
+		if message.Get("error").Exists() {
+			incoming.err = errors.New(message.Get("error").Get("message").String())
+		}
+	}
+	incoming.received.Broadcast()
+}
+
+// fail end the stream with the error, the chunks not read yet are discarded. Must be called with the mutex locked.
+func (incoming *incomingStream) fail(err error) {
+	incoming.end = incoming.next
+	incoming.chunks = make(map[int][]byte)
+	incoming.err = err
+	incoming.received.Broadcast()
+}
+
+func (incoming *incomingStream) Read(buffer []byte) (int, error) {
+	incoming.mutex.Lock()
+	defer incoming.mutex.Unlock()
+	for {
+		if len(incoming.current) > 0 {
+			read := copy(buffer, incoming.current)
+			incoming.current = incoming.current[read:]
+			return read, nil
+		}
+		if incoming.closed {
+			return 0, io.ErrClosedPipe
+		}
+		if chunk, exists := incoming.chunks[incoming.next]; exists {
+			delete(incoming.chunks, incoming.next)
+			incoming.current = chunk
+			go incoming.sendControl(map[string]interface{}{"ack": incoming.next})
+			incoming.next++
+			continue
+		}
+		if incoming.end >= 0 && incoming.next >= incoming.end {
+			incoming.pubsub.removeIncomingStream(incoming.id)
+			if incoming.err != nil {
+				return 0, incoming.err
+			}
+			return 0, io.EOF
+		}
+		incoming.received.Wait()
+	}
+}
+
+// Close stop reading the stream. When the stream did not end the sender is notified to stop sending.
+func (incoming *incomingStream) Close() error {
+	incoming.mutex.Lock()
+	defer incoming.mutex.Unlock()
+	if incoming.closed {
+		return nil
+	}
+	incoming.closed = true
+	incoming.chunks = make(map[int][]byte)
+	incoming.current = nil
+	incoming.received.Broadcast()
+	if incoming.end >= 0 {
+		incoming.pubsub.removeIncomingStream(incoming.id)
+		return nil
+	}
+	go incoming.sendControl(map[string]interface{}{"cancel": true})
+	// keep the stream until the chunks already sent are discarded
+	time.AfterFunc(incoming.pubsub.broker.Config.RequestTimeout, func() {
+		incoming.pubsub.removeIncomingStream(incoming.id)
+	})
+	return nil
+}
+
+// sendControl send an acknowledgement or cancellation of the stream to the sender.
+func (incoming *incomingStream) sendControl(values map[string]interface{}) {
+	values["sender"] = incoming.pubsub.broker.LocalNode().GetID()
+	values["ver"] = version.NucleoProtocol()
+	values["id"] = incoming.id
+	incoming.pubsub.publishStreamPacket("STREAM", incoming.sender, values)
+}
+
+// onStreamNodeDisconnected fail the streams received from the node and cancel the streams sent to it.
+func (pubsub *PubSub) onStreamNodeDisconnected(nodeID string) {
+	var failed []*incomingStream
+	pubsub.streamsMutex.Lock()
+	for _, incoming := range pubsub.incomingStreams {
+		if incoming.sender == nodeID {
+			failed = append(failed, incoming)
+		}
+	}
+	for _, outgoing := range pubsub.outgoingStreams {
+		if outgoing.targetNodeID == nodeID {
+			outgoing.cancel()
+		}
+	}
+	pubsub.streamsMutex.Unlock()
+
+	for _, incoming := range failed {
+		incoming.mutex.Lock()
+		if !incoming.closed {
+			incoming.fail(fmt.Errorf("Node %s disconnected. The stream was canceled.", nodeID))
+		}
+		incoming.mutex.Unlock()
+	}
+}
+
+// requestStreamHandler handles the packets of a request with stream params. The action is invoked when
+// the first packet arrives, with the stream as params, and the stream is closed when the action ends.
+func (pubsub *PubSub) requestStreamHandler(message nucleo.Payload) {
+	incoming, created := pubsub.incomingStream(message.Get("id").String(), message.Get("sender").String())
+	if incoming == nil {
+		pubsub.logger.Debugln("requestStreamHandler() - discarding stream packet -> stream already finished, id: ", message.Get("id").String())
+		return
+	}
+	incoming.push(message, "params")
+	if !created {
+		return
+	}
+	values := pubsub.serializer.PayloadToContextMap(message)
+	values["params"] = incoming
+	actionContext := context.ActionContext(pubsub.broker, values)
+	go func() {
+		result := <-pubsub.broker.ActionDelegate(actionContext)
+		incoming.Close()
+		pubsub.sendResponse(actionContext, result)
+	}()
+}
+
+// responseStreamHandler handles the packets of a stream response. The pending request receives the
+// stream when the first packet arrives.
+func (pubsub *PubSub) responseStreamHandler(message nucleo.Payload) {
+	id := message.Get("id").String()
+	pubsub.pendingRequestsMutex.Lock()
+	pubsub.streamsMutex.Lock()
+	incoming, exists := pubsub.incomingStreams[id]
+	pubsub.streamsMutex.Unlock()
+	var resultChan *chan nucleo.Payload
+	if !exists {
+		request, pending := pubsub.pendingRequests[id]
+		if !pending {
+			pubsub.pendingRequestsMutex.Unlock()
+			pubsub.logger.Debugln("responseStreamHandler() - discarding stream packet -> request does not exist for id: ", id)
+			return
+		}
+		request.timer.Stop()
+		delete(pubsub.pendingRequests, id)
+		incoming, _ = pubsub.incomingStream(id, message.Get("sender").String())
+		if incoming == nil {
+			pubsub.pendingRequestsMutex.Unlock()
+			pubsub.logger.Debugln("responseStreamHandler() - discarding stream packet -> stream already finished, id: ", id)
+			return
+		}
+		resultChan = request.resultChan
+	}
+	pubsub.pendingRequestsMutex.Unlock()
+
+	// the stream is delivered without holding the lock, the caller may not be reading the channel anymore
+	if resultChan != nil {
+		*resultChan <- payload.New(incoming)
+	}
+	incoming.push(message, "data")
+}
@@ -0,0 +1,199 @@
+package pubsub
+
+import (
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	bus "github.com/Bendomey/nucleo-go/emitter"
+	"github.com/Bendomey/nucleo-go/payload"
+	"github.com/Bendomey/nucleo-go/serializer"
+	"github.com/Bendomey/nucleo-go/transit"
+	log "github.com/sirupsen/logrus"
+)
+
+// testTransport record the published packets.
+type testTransport struct {
+	mutex     *sync.Mutex
+	published map[string][]nucleo.Payload
+}
+
+func (transport *testTransport) Connect() chan error                                                { return make(chan error, 1) }
+func (transport *testTransport) Disconnect() chan error                                             { return make(chan error, 1) }
+func (transport *testTransport) Subscribe(command, nodeID string, handler transit.TransportHandler) {}
+func (transport *testTransport) SetPrefix(prefix string)                                            {}
+func (transport *testTransport) SetNodeID(nodeID string)                                            {}
+func (transport *testTransport) SetSerializer(serializer serializer.Serializer)                     {}
+
+func (transport *testTransport) Publish(command, nodeID string, message nucleo.Payload) {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	transport.published[command] = append(transport.published[command], message)
+}
+
+func (transport *testTransport) packets(command string) []nucleo.Payload {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	return append([]nucleo.Payload{}, transport.published[command]...)
+}
+
+type testNode struct {
+	nucleo.Node
+}
+
+func (node testNode) GetID() string {
+	return "worker"
+}
+
+// createTestPubSub create a transit publishing in a test transport. The calls are handled by the action.
+func createTestPubSub(action func(params nucleo.Payload) interface{}) (*PubSub, *testTransport) {
+	logger := log.New()
+	logger.SetLevel(log.FatalLevel)
+	emitter := bus.Construct()
+	config := nucleo.DefaultConfig
+	config.RequestTimeout = time.Second
+	delegates := &nucleo.BrokerDelegates{
+		Config:    config,
+		Bus:       func() *bus.Emitter { return emitter },
+		Logger:    func(name string, value string) *log.Entry { return logger.WithField(name, value) },
+		LocalNode: func() nucleo.Node { return testNode{} },
+		ActionDelegate: func(context nucleo.BrokerContext, opts ...nucleo.Options) chan nucleo.Payload {
+			result := make(chan nucleo.Payload, 1)
+			result <- payload.New(action(context.Payload()))
+			return result
+		},
+	}
+	pubsub := Create(delegates).(*PubSub)
+	transport := &testTransport{mutex: &sync.Mutex{}, published: map[string][]nucleo.Payload{}}
+	pubsub.transport = transport
+	return pubsub, transport
+}
+
+// requestPacket create the packet of a stream request sent by the client. The chunk is nil in the last packet.
+func requestPacket(pubsub *PubSub, id string, seq int, chunk []byte) nucleo.Payload {
+	values := map[string]interface{}{
+		"sender": "client",
+		"id":     id,
+		"action": "files.upload",
+		"level":  1,
+		"seq":    seq,
+		"stream": chunk != nil,
+		"params": nil,
+	}
+	if chunk != nil {
+		values["params"] = base64.StdEncoding.EncodeToString(chunk)
+	}
+	message, _ := pubsub.serializer.MapToPayload(&values)
+	return message
+}
+
+func waitFor(t *testing.T, message string, condition func() bool) {
+	t.Helper()
+	for start := time.Now(); !condition(); time.Sleep(time.Millisecond) {
+		if time.Since(start) > time.Second {
+			t.Fatal(message)
+		}
+	}
+}
+
+func TestStreamChunksOutOfOrder(t *testing.T) {
+	var calls int32
+	read := make(chan string, 1)
+	pubsub, transport := createTestPubSub(func(params nucleo.Payload) interface{} {
+		atomic.AddInt32(&calls, 1)
+		content, _ := ioutil.ReadAll(params.Value().(io.Reader))
+		read <- string(content)
+		return "done"
+	})
+
+	pubsub.requestStreamHandler(requestPacket(pubsub, "upload-1", 2, nil))
+	pubsub.requestStreamHandler(requestPacket(pubsub, "upload-1", 1, []byte("world")))
+	pubsub.requestStreamHandler(requestPacket(pubsub, "upload-1", 0, []byte("hello ")))
+	if content := <-read; content != "hello world" {
+		t.Fatalf("expected the chunks in order, got %q", content)
+	}
+	waitFor(t, "expected the response of the action", func() bool { return len(transport.packets("RES")) == 1 })
+
+	// a late packet of the finished stream does not start the action again
+	pubsub.requestStreamHandler(requestPacket(pubsub, "upload-1", 1, []byte("world")))
+	time.Sleep(20 * time.Millisecond)
+	if calls := atomic.LoadInt32(&calls); calls != 1 {
+		t.Fatalf("expected the action to be called once, got %d", calls)
+	}
+}
+
+func TestStreamCanceledByTheAction(t *testing.T) {
+	var calls int32
+	pubsub, transport := createTestPubSub(func(params nucleo.Payload) interface{} {
+		atomic.AddInt32(&calls, 1)
+		// the action returns without reading the stream
+		return "rejected"
+	})
+	pubsub.broker.Config.RequestTimeout = 10 * time.Millisecond
+
+	pubsub.requestStreamHandler(requestPacket(pubsub, "upload-2", 0, []byte("hello")))
+	waitFor(t, "expected the sender to be notified", func() bool {
+		cancels := transport.packets("STREAM")
+		return len(cancels) == 1 && cancels[0].Get("cancel").Bool() && cancels[0].Get("id").String() == "upload-2"
+	})
+
+	// the chunks sent before the sender stopped are discarded, before and after the stream is removed
+	pubsub.requestStreamHandler(requestPacket(pubsub, "upload-2", 1, []byte("world")))
+	time.Sleep(50 * time.Millisecond)
+	pubsub.requestStreamHandler(requestPacket(pubsub, "upload-2", 2, []byte("!")))
+	time.Sleep(20 * time.Millisecond)
+	if calls := atomic.LoadInt32(&calls); calls != 1 {
+		t.Fatalf("expected the action to be called once, got %d", calls)
+	}
+	if responses := len(transport.packets("RES")); responses != 1 {
+		t.Fatalf("expected a single response, got %d", responses)
+	}
+}
+
+// endlessReader return chunks until it is closed.
+type endlessReader struct {
+	closed chan struct{}
+}
+
+func (reader *endlessReader) Read(buffer []byte) (int, error) {
+	return copy(buffer, "chunk"), nil
+}
+
+func (reader *endlessReader) Close() error {
+	close(reader.closed)
+	return nil
+}
+
+func TestStreamBackpressure(t *testing.T) {
+	pubsub, transport := createTestPubSub(nil)
+	source := &endlessReader{closed: make(chan struct{})}
+	go pubsub.sendStream("RES", "client", "download-1", source, func(seq int, chunk []byte, err error) map[string]interface{} {
+		return streamPacket(map[string]interface{}{"id": "download-1"}, "data", "dataType", seq, chunk)
+	})
+
+	// without acknowledgements the sender stops after a window of chunks
+	waitFor(t, "expected a window of chunks", func() bool { return len(transport.packets("RES")) == streamWindow })
+	time.Sleep(20 * time.Millisecond)
+	if sent := len(transport.packets("RES")); sent != streamWindow {
+		t.Fatalf("expected %d chunks without acknowledgement, got %d", streamWindow, sent)
+	}
+
+	ack := map[string]interface{}{"id": "download-1", "ack": 0}
+	message, _ := pubsub.serializer.MapToPayload(&ack)
+	pubsub.streamHandler()(message)
+	waitFor(t, "expected another chunk after the acknowledgement", func() bool { return len(transport.packets("RES")) == streamWindow+1 })
+
+	cancel := map[string]interface{}{"id": "download-1", "cancel": true}
+	message, _ = pubsub.serializer.MapToPayload(&cancel)
+	pubsub.streamHandler()(message)
+	select {
+	case <-source.closed:
+	case <-time.After(time.Second):
+		t.Fatal("expected the source to be closed when the receiver cancels the stream")
+	}
+}
@@ -0,0 +1,97 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/payload"
+	"github.com/Bendomey/nucleo-go/serializer"
+	"github.com/alicebob/miniredis/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+func createTestTransporter(t *testing.T, url string) *RedisTransporter {
+	logger := log.WithField("transport", "redis-test")
+	transporter := CreateRedisTransporter(RedisOptions{
+		URL:        url,
+		Logger:     logger,
+		Serializer: serializer.CreateJSONSerializer(logger),
+	}).(*RedisTransporter)
+	transporter.SetPrefix("MOL-test")
+	if err := <-transporter.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { <-transporter.Disconnect() })
+	return transporter
+}
+
+func receive(t *testing.T, received chan nucleo.Payload) nucleo.Payload {
+	select {
+	case message := <-received:
+		return message
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a message")
+	}
+	return nil
+}
+
+func TestSubscribeThenPublish(t *testing.T) {
+	server := miniredis.RunT(t)
+	url := "redis://" + server.Addr()
+	subscriber := createTestTransporter(t, url)
+	publisher := createTestTransporter(t, url)
+
+	received := make(chan nucleo.Payload, 1)
+	subscriber.Subscribe("REQ", "node-1", func(message nucleo.Payload) {
+		received <- message
+	})
+	if channels := server.PubSubChannels("MOL-test.*"); len(channels) != 1 || channels[0] != "MOL-test.REQ.node-1" {
+		t.Fatalf("expected the subscription to be confirmed when Subscribe returns, got %v", channels)
+	}
+
+	// published right after Subscribe, it would be lost if the subscription was not confirmed yet
+	publisher.Publish("REQ", "node-1", payload.New(map[string]interface{}{"sender": "node-2", "action": "math.add"}))
+	if action := receive(t, received).Get("action").String(); action != "math.add" {
+		t.Fatalf("expected the REQ packet, got action %q", action)
+	}
+
+	publisher.Publish("REQ", "node-3", payload.New(map[string]interface{}{"sender": "node-2"}))
+	select {
+	case message := <-received:
+		t.Fatalf("expected packets of other nodes to be ignored, got %v", message)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestBroadcastToAllSubscribers(t *testing.T) {
+	server := miniredis.RunT(t)
+	url := "redis://" + server.Addr()
+	first := createTestTransporter(t, url)
+	second := createTestTransporter(t, url)
+
+	received := make(chan nucleo.Payload, 2)
+	handler := func(message nucleo.Payload) {
+		received <- message
+	}
+	first.Subscribe("HEARTBEAT", "", handler)
+	second.Subscribe("HEARTBEAT", "", handler)
+
+	first.Publish("HEARTBEAT", "", payload.New(map[string]interface{}{"sender": "node-1", "cpu": 10}))
+	for index := 0; index < 2; index++ {
+		if cpu := receive(t, received).Get("cpu").Int(); cpu != 10 {
+			t.Fatalf("expected the heartbeat, got cpu %d", cpu)
+		}
+	}
+}
+
+func TestConnectError(t *testing.T) {
+	transporter := CreateRedisTransporter(RedisOptions{
+		URL:     "redis://127.0.0.1:1",
+		Timeout: 200 * time.Millisecond,
+		Logger:  log.WithField("transport", "redis-test"),
+	})
+	if err := <-transporter.Connect(); err == nil {
+		t.Fatal("expected a connection error")
+	}
+}
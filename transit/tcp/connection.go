@@ -0,0 +1,292 @@
+package tcp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Frames are prefixed with their length (4 bytes, big endian) followed by the frame type.
+const (
+	frameHello byte = iota + 1
+	framePacket
+	frameBroadcast
+	frameGossipRequest
+	frameGossipResponse
+)
+
+const (
+	maxFrameSize = 64 * 1024 * 1024
+	writeTimeout = 10 * time.Second
+)
+
+// hello is the first frame sent on both sides of a connection.
+type hello struct {
+	Prefix  string `json:"prefix"`
+	NodeID  string `json:"nodeID"`
+	Address string `json:"address"`
+}
+
+type connection struct {
+	conn     net.Conn
+	outgoing bool
+	mutex    sync.Mutex
+}
+
+func (connection *connection) write(frameType byte, body []byte) error {
+	frame := make([]byte, 5+len(body))
+	binary.BigEndian.PutUint32(frame, uint32(len(body)+1))
+	frame[4] = frameType
+	copy(frame[5:], body)
+
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+	connection.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err := connection.conn.Write(frame)
+	return err
+}
+
+func readFrame(reader *bufio.Reader) (byte, []byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(header)
+	if size < 1 || size > maxFrameSize {
+		return 0, nil, errors.Errorf("TCP invalid frame size: %d", size)
+	}
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(reader, frame); err != nil {
+		return 0, nil, err
+	}
+	return frame[0], frame[1:], nil
+}
+
+// packetBody encode the command and the serialized packet of a packet frame.
+func packetBody(command string, data []byte) []byte {
+	body := make([]byte, 1+len(command)+len(data))
+	body[0] = byte(len(command))
+	copy(body[1:], command)
+	copy(body[1+len(command):], data)
+	return body
+}
+
+func parsePacketBody(body []byte) (string, []byte, error) {
+	if len(body) < 1 || len(body) < 1+int(body[0]) {
+		return "", nil, errors.New("TCP invalid packet frame")
+	}
+	size := int(body[0])
+	return string(body[1 : 1+size]), body[1+size:], nil
+}
+
+// peer is a known node of the cluster. The connection is dialed on demand.
+type peer struct {
+	nodeID     string
+	address    string
+	connection *connection
+	seen       time.Time
+	dialMutex  sync.Mutex
+}
+
+func (t *TcpTransporter) addConnection(conn net.Conn, outgoing bool) *connection {
+	connection := &connection{conn: conn, outgoing: outgoing}
+	t.mutex.Lock()
+	t.connections[connection] = true
+	t.mutex.Unlock()
+	return connection
+}
+
+func (t *TcpTransporter) removeConnection(connection *connection) {
+	connection.conn.Close()
+	t.mutex.Lock()
+	delete(t.connections, connection)
+	for _, peer := range t.peers {
+		if peer.connection == connection {
+			peer.connection = nil
+		}
+	}
+	for address, seed := range t.seeds {
+		if seed == connection {
+			delete(t.seeds, address)
+		}
+	}
+	t.mutex.Unlock()
+}
+
+func (t *TcpTransporter) localHello() []byte {
+	body, _ := json.Marshal(hello{Prefix: t.prefix, NodeID: t.nodeID, Address: t.address})
+	return body
+}
+
+// dial open a connection to the address and start reading it.
+func (t *TcpTransporter) dial(address string) (*connection, error) {
+	conn, err := net.DialTimeout("tcp", address, t.opts.DialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	connection := t.addConnection(conn, true)
+	if err := connection.write(frameHello, t.localHello()); err != nil {
+		t.removeConnection(connection)
+		return nil, err
+	}
+	go t.readLoop(connection)
+	return connection, nil
+}
+
+// readLoop read the frames of the connection until it is closed. The first frame must be the hello of the remote node.
+func (t *TcpTransporter) readLoop(connection *connection) {
+	defer t.removeConnection(connection)
+	reader := bufio.NewReader(connection.conn)
+
+	frameType, body, err := readFrame(reader)
+	if err != nil || frameType != frameHello {
+		t.logger.Debugln("TCP connection closed before hello -> ", err)
+		return
+	}
+	remote := hello{}
+	if err := json.Unmarshal(body, &remote); err != nil || remote.Prefix != t.prefix || remote.NodeID == t.nodeID {
+		t.logger.Debugln("TCP rejected connection from ", connection.conn.RemoteAddr(), " - node: ", remote.NodeID)
+		return
+	}
+	if !connection.outgoing {
+		if err := connection.write(frameHello, t.localHello()); err != nil {
+			return
+		}
+	}
+	if t.addPeer(remote.NodeID, remote.Address, connection) {
+		go t.gossipWith(remote.NodeID, gossipMessage{Digest: t.digest()})
+	}
+
+	for {
+		frameType, body, err := readFrame(reader)
+		if err != nil {
+			if err != io.EOF {
+				t.logger.Debugln("TCP read error from ", remote.NodeID, " -> ", err)
+			}
+			return
+		}
+		switch frameType {
+		case framePacket, frameBroadcast:
+			command, data, err := parsePacketBody(body)
+			if err != nil {
+				t.logger.Errorln(err)
+				return
+			}
+			target := ""
+			if frameType == framePacket {
+				target = t.nodeID
+			}
+			t.dispatch(command, target, data)
+			if command == "DISCONNECT" {
+				t.removeNode(remote.NodeID)
+			}
+		case frameGossipRequest, frameGossipResponse:
+			t.gossipHandler(connection, frameType, body)
+		}
+	}
+}
+
+// addPeer add or update a node, with the connection to use when it has none.
+func (t *TcpTransporter) addPeer(nodeID, address string, connection *connection) bool {
+	if nodeID == t.nodeID {
+		return false
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	existing, exists := t.peers[nodeID]
+	if !exists {
+		existing = &peer{nodeID: nodeID}
+		t.peers[nodeID] = existing
+	}
+	existing.seen = time.Now()
+	if address != "" {
+		existing.address = address
+	}
+	if existing.connection == nil && connection != nil {
+		existing.connection = connection
+	}
+	return !exists
+}
+
+func (t *TcpTransporter) peerIDs() []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	result := make([]string, 0, len(t.peers))
+	for nodeID := range t.peers {
+		result = append(result, nodeID)
+	}
+	return result
+}
+
+// peerConnection return the connection of the node, dialing it when there is none.
+func (t *TcpTransporter) peerConnection(nodeID string) (*connection, error) {
+	t.mutex.Lock()
+	peer, exists := t.peers[nodeID]
+	t.mutex.Unlock()
+	if !exists {
+		return nil, errors.New("unknown node")
+	}
+
+	peer.dialMutex.Lock()
+	defer peer.dialMutex.Unlock()
+	t.mutex.Lock()
+	connection, address := peer.connection, peer.address
+	t.mutex.Unlock()
+	if connection != nil {
+		return connection, nil
+	}
+	if address == "" {
+		return nil, errors.New("unknown node address")
+	}
+	connection, err := t.dial(address)
+	if err != nil {
+		return nil, err
+	}
+	t.mutex.Lock()
+	if peer.connection == nil {
+		peer.connection = connection
+	}
+	t.mutex.Unlock()
+	return connection, nil
+}
+
+// send write the frame to the node. The frame is sent again on a new connection when the current one is broken.
+func (t *TcpTransporter) send(nodeID string, frameType byte, body []byte) error {
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		var connection *connection
+		connection, err = t.peerConnection(nodeID)
+		if err != nil {
+			return err
+		}
+		if err = connection.write(frameType, body); err == nil {
+			return nil
+		}
+		t.removeConnection(connection)
+	}
+	return err
+}
+
+// removeNode forget the node, after it disconnected or timed out.
+func (t *TcpTransporter) removeNode(nodeID string) {
+	t.mutex.Lock()
+	peer, exists := t.peers[nodeID]
+	delete(t.peers, nodeID)
+	now := time.Now()
+	for _, entry := range t.entries {
+		if entry.NodeID == nodeID {
+			entry.Packet = nil
+			entry.received = now
+		}
+	}
+	t.mutex.Unlock()
+	if exists && peer.connection != nil {
+		peer.connection.conn.Close()
+	}
+}
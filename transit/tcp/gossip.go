@@ -0,0 +1,215 @@
+package tcp
+
+import (
+	"encoding/json"
+	"math/rand"
+	"time"
+)
+
+// gossipEntry is the last INFO or HEARTBEAT packet of a node. The version is set by the node that
+// created the packet, so the newest packet wins. Entries of removed nodes have no packet.
+type gossipEntry struct {
+	NodeID  string `json:"nodeID"`
+	Command string `json:"command"`
+	Version int64  `json:"version"`
+	Address string `json:"address"`
+	Packet  []byte `json:"packet,omitempty"`
+
+	received time.Time
+}
+
+func (entry *gossipEntry) key() string {
+	return entry.Command + "." + entry.NodeID
+}
+
+// gossipMessage is the body of the gossip frames. A request has the digest (versions) of the
+// entries of the sender, the response has the entries newer than the digest and, for a request,
+// the digest of the responder so the sender can send back the entries it is missing.
+type gossipMessage struct {
+	Digest  map[string]int64 `json:"digest,omitempty"`
+	Entries []gossipEntry    `json:"entries,omitempty"`
+}
+
+// updateLocalEntry store an INFO or HEARTBEAT packet of the local node, to be spread by the gossip rounds.
+func (t *TcpTransporter) updateLocalEntry(command string, data []byte) {
+	entry := &gossipEntry{NodeID: t.nodeID, Command: command, Version: time.Now().UnixNano(), Address: t.address, Packet: data, received: time.Now()}
+	t.mutex.Lock()
+	if existing, exists := t.entries[entry.key()]; exists && existing.Version >= entry.Version {
+		entry.Version = existing.Version + 1
+	}
+	t.entries[entry.key()] = entry
+	t.mutex.Unlock()
+}
+
+func (t *TcpTransporter) digest() map[string]int64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	result := make(map[string]int64, len(t.entries))
+	for key, entry := range t.entries {
+		result[key] = entry.Version
+	}
+	return result
+}
+
+// newerEntries return the entries newer than the digest.
+func (t *TcpTransporter) newerEntries(digest map[string]int64) []gossipEntry {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	result := []gossipEntry{}
+	for key, entry := range t.entries {
+		if entry.Packet != nil && entry.Version > digest[key] {
+			result = append(result, *entry)
+		}
+	}
+	return result
+}
+
+// applyEntries store the entries newer than the known ones and deliver their packets to the handlers.
+// Entries are skipped until the handlers of their command are subscribed, they will be received again.
+func (t *TcpTransporter) applyEntries(entries []gossipEntry) {
+	for index := range entries {
+		entry := entries[index]
+		if entry.NodeID == t.nodeID || entry.Packet == nil || !t.hasHandlers(entry.Command) {
+			continue
+		}
+		t.mutex.Lock()
+		existing, exists := t.entries[entry.key()]
+		isNewer := !exists || entry.Version > existing.Version
+		if isNewer {
+			entry.received = time.Now()
+			t.entries[entry.key()] = &entry
+		}
+		t.mutex.Unlock()
+		if isNewer {
+			t.addPeer(entry.NodeID, entry.Address, nil)
+			t.dispatch(entry.Command, "", entry.Packet)
+		}
+	}
+}
+
+func (t *TcpTransporter) hasHandlers(command string) bool {
+	t.handlersMutex.RLock()
+	defer t.handlersMutex.RUnlock()
+	return len(t.handlers[command]) > 0
+}
+
+func (t *TcpTransporter) gossipHandler(connection *connection, frameType byte, body []byte) {
+	message := gossipMessage{}
+	if err := json.Unmarshal(body, &message); err != nil {
+		t.logger.Errorln("TCP invalid gossip frame -> ", err)
+		return
+	}
+	t.applyEntries(message.Entries)
+	if message.Digest == nil {
+		return
+	}
+	response := gossipMessage{Entries: t.newerEntries(message.Digest)}
+	if frameType == frameGossipRequest {
+		response.Digest = t.digest()
+	} else if len(response.Entries) == 0 {
+		return
+	}
+	t.writeGossip(connection, frameGossipResponse, response)
+}
+
+func (t *TcpTransporter) writeGossip(connection *connection, frameType byte, message gossipMessage) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		t.logger.Errorln("TCP can't encode gossip frame -> ", err)
+		return
+	}
+	if err := connection.write(frameType, body); err != nil {
+		t.logger.Debugln("TCP can't send gossip frame -> ", err)
+		t.removeConnection(connection)
+	}
+}
+
+// gossipRound forget the nodes without news for NodeTimeout and exchange the entries with random nodes.
+// The seeds are contacted until they are known nodes.
+func (t *TcpTransporter) gossipRound() {
+	t.expireNodes()
+	request := gossipMessage{Digest: t.digest()}
+
+	nodeIDs := t.peerIDs()
+	rand.Shuffle(len(nodeIDs), func(i, j int) {
+		nodeIDs[i], nodeIDs[j] = nodeIDs[j], nodeIDs[i]
+	})
+	if len(nodeIDs) > t.opts.GossipFanout {
+		nodeIDs = nodeIDs[:t.opts.GossipFanout]
+	}
+	for _, nodeID := range nodeIDs {
+		go t.gossipWith(nodeID, request)
+	}
+	for _, address := range t.unknownSeeds() {
+		go t.gossipWithSeed(address, request)
+	}
+}
+
+func (t *TcpTransporter) gossipWith(nodeID string, request gossipMessage) {
+	connection, err := t.peerConnection(nodeID)
+	if err != nil {
+		t.logger.Debugln("TCP can't gossip with ", nodeID, " -> ", err)
+		return
+	}
+	t.writeGossip(connection, frameGossipRequest, request)
+}
+
+func (t *TcpTransporter) gossipWithSeed(address string, request gossipMessage) {
+	t.mutex.Lock()
+	connection := t.seeds[address]
+	t.mutex.Unlock()
+	if connection == nil {
+		var err error
+		if connection, err = t.dial(address); err != nil {
+			t.logger.Debugln("TCP can't reach seed ", address, " -> ", err)
+			return
+		}
+		t.mutex.Lock()
+		t.seeds[address] = connection
+		t.mutex.Unlock()
+	}
+	t.writeGossip(connection, frameGossipRequest, request)
+}
+
+// unknownSeeds return the seeds that are not the address of a known node.
+func (t *TcpTransporter) unknownSeeds() []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	known := map[string]bool{t.address: true}
+	for _, peer := range t.peers {
+		known[peer.address] = true
+	}
+	result := []string{}
+	for _, address := range t.opts.Seeds {
+		if !known[address] {
+			result = append(result, address)
+		}
+	}
+	return result
+}
+
+// tombstoneFactor is how many NodeTimeout the entries of a removed node are kept. The other nodes forget
+// the removed node within NodeTimeout, until then they can gossip its old entries, which must not bring it back.
+const tombstoneFactor = 3
+
+// expireNodes forget the nodes without news for NodeTimeout and the entries of removed nodes.
+func (t *TcpTransporter) expireNodes() {
+	now := time.Now()
+	t.mutex.Lock()
+	for key, entry := range t.entries {
+		if entry.Packet == nil && now.Sub(entry.received) > tombstoneFactor*t.opts.NodeTimeout {
+			delete(t.entries, key)
+		}
+	}
+	expired := []string{}
+	for nodeID, peer := range t.peers {
+		if now.Sub(peer.seen) > t.opts.NodeTimeout {
+			expired = append(expired, nodeID)
+		}
+	}
+	t.mutex.Unlock()
+	for _, nodeID := range expired {
+		t.logger.Debugln("TCP node expired -> ", nodeID)
+		t.removeNode(nodeID)
+	}
+}
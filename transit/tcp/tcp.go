@@ -0,0 +1,367 @@
+package tcp
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/serializer"
+	"github.com/Bendomey/nucleo-go/transit"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var DefaultConfig = TcpOptions{
+	UdpAddress:   "239.0.0.0",
+	UdpPort:      4445,
+	UdpPeriod:    5 * time.Second,
+	GossipPeriod: time.Second,
+	GossipFanout: 3,
+	NodeTimeout:  30 * time.Second,
+	DialTimeout:  5 * time.Second,
+}
+
+type TcpOptions struct {
+	// Host and Port the transporter listens on. Port 0 picks a random port.
+	Host string
+	Port int
+	// PublicHost is the host announced to the other nodes, by default the first non loopback IPv4 address.
+	PublicHost string
+	// Seeds are the host:port addresses of nodes to contact when UDP discovery is not available.
+	Seeds []string
+
+	DisableUdpDiscovery bool
+	// UdpAddress is the multicast group (or broadcast address) and UdpPort the port of the UDP announcements.
+	UdpAddress string
+	UdpPort    int
+	UdpPeriod  time.Duration
+
+	// GossipPeriod is the interval of the gossip rounds, each one exchanging INFO and HEARTBEAT
+	// packets with GossipFanout random nodes.
+	GossipPeriod time.Duration
+	GossipFanout int
+	// NodeTimeout is the time without news of a node before it is forgotten.
+	NodeTimeout time.Duration
+	DialTimeout time.Duration
+
+	Logger     *log.Entry
+	Serializer serializer.Serializer
+}
+
+type TcpTransporter struct {
+	opts       *TcpOptions
+	prefix     string
+	nodeID     string
+	address    string
+	logger     *log.Entry
+	serializer serializer.Serializer
+
+	listener  net.Listener
+	udp       *net.UDPConn
+	done      chan struct{}
+	closeOnce *sync.Once
+
+	handlers      map[string][]transit.TransportHandler
+	handlersMutex sync.RWMutex
+
+	peers       map[string]*peer
+	connections map[*connection]bool
+	seeds       map[string]*connection
+	entries     map[string]*gossipEntry
+	mutex       sync.Mutex
+}
+
+func mergeConfigs(baseConfig TcpOptions, userConfig TcpOptions) TcpOptions {
+	if userConfig.Host != "" {
+		baseConfig.Host = userConfig.Host
+	}
+	if userConfig.Port != 0 {
+		baseConfig.Port = userConfig.Port
+	}
+	if userConfig.PublicHost != "" {
+		baseConfig.PublicHost = userConfig.PublicHost
+	}
+	if len(userConfig.Seeds) != 0 {
+		baseConfig.Seeds = userConfig.Seeds
+	}
+	baseConfig.DisableUdpDiscovery = userConfig.DisableUdpDiscovery
+	if userConfig.UdpAddress != "" {
+		baseConfig.UdpAddress = userConfig.UdpAddress
+	}
+	if userConfig.UdpPort != 0 {
+		baseConfig.UdpPort = userConfig.UdpPort
+	}
+	if userConfig.UdpPeriod != 0 {
+		baseConfig.UdpPeriod = userConfig.UdpPeriod
+	}
+	if userConfig.GossipPeriod != 0 {
+		baseConfig.GossipPeriod = userConfig.GossipPeriod
+	}
+	if userConfig.GossipFanout != 0 {
+		baseConfig.GossipFanout = userConfig.GossipFanout
+	}
+	if userConfig.NodeTimeout != 0 {
+		baseConfig.NodeTimeout = userConfig.NodeTimeout
+	}
+	if userConfig.DialTimeout != 0 {
+		baseConfig.DialTimeout = userConfig.DialTimeout
+	}
+	if userConfig.Logger != nil {
+		baseConfig.Logger = userConfig.Logger
+	}
+	if userConfig.Serializer != nil {
+		baseConfig.Serializer = userConfig.Serializer
+	}
+	return baseConfig
+}
+
+// ParseOptions create the TCP options of a transporter url, e.g. tcp://0.0.0.0:6000?seeds=10.0.0.2:6000,10.0.0.3:6000.
+// The options are read from the transporter options map and then from the url query, which takes precedence:
+//   - seeds: host:port addresses of nodes to contact, as a list or comma separated.
+//   - udpDiscovery: false to disable the UDP announcements.
+//   - udpAddress, udpPort, udpPeriod: multicast group (or broadcast address), port and interval of the announcements.
+//   - gossipPeriod, gossipFanout: interval and number of nodes of the gossip rounds.
+//   - nodeTimeout: time (milliseconds or duration) without news of a node before it is forgotten.
+//   - publicHost: host announced to the other nodes.
+func ParseOptions(rawUrl string, options map[string]interface{}) (TcpOptions, error) {
+	result := TcpOptions{}
+	parsed, values, err := transit.URLOptions(rawUrl, options)
+	if err != nil {
+		return result, errors.Wrap(err, "TCP invalid url")
+	}
+	result.Host = parsed.Hostname()
+	if parsed.Port() != "" {
+		if result.Port, err = strconv.Atoi(parsed.Port()); err != nil {
+			return result, invalidOption("port", parsed.Port())
+		}
+	}
+	for key, value := range values {
+		if err := result.setOption(key, value); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// setOption set one of the options parsed from the url or the options map.
+func (options *TcpOptions) setOption(key string, value interface{}) error {
+	var valid bool
+	switch key {
+	case "seeds":
+		options.Seeds, valid = transit.ToStringList(value)
+	case "udpDiscovery":
+		var enabled bool
+		enabled, valid = transit.ToBool(value)
+		options.DisableUdpDiscovery = !enabled
+	case "udpAddress":
+		options.UdpAddress, valid = value.(string)
+	case "udpPort":
+		options.UdpPort, valid = transit.ToInt(value)
+	case "udpPeriod":
+		options.UdpPeriod, valid = transit.ToDuration(value)
+	case "gossipPeriod":
+		options.GossipPeriod, valid = transit.ToDuration(value)
+	case "gossipFanout":
+		options.GossipFanout, valid = transit.ToInt(value)
+	case "nodeTimeout":
+		options.NodeTimeout, valid = transit.ToDuration(value)
+	case "publicHost":
+		options.PublicHost, valid = value.(string)
+	default:
+		return nil
+	}
+	if !valid {
+		return invalidOption(key, value)
+	}
+	return nil
+}
+
+func invalidOption(key string, value interface{}) error {
+	return errors.New(fmt.Sprint("TCP invalid option ", key, ": ", value))
+}
+
+func CreateTcpTransporter(options TcpOptions) transit.Transport {
+	options = mergeConfigs(DefaultConfig, options)
+
+	return &TcpTransporter{
+		opts:       &options,
+		logger:     options.Logger,
+		serializer: options.Serializer,
+		handlers:   map[string][]transit.TransportHandler{},
+	}
+}
+
+func (t *TcpTransporter) Connect() chan error {
+	endChan := make(chan error)
+	go func() {
+		listener, err := net.Listen("tcp", net.JoinHostPort(t.opts.Host, strconv.Itoa(t.opts.Port)))
+		if err != nil {
+			endChan <- errors.Wrap(err, "TCP failed to listen")
+			return
+		}
+		t.listener = listener
+		t.address = net.JoinHostPort(t.publicHost(), strconv.Itoa(listener.Addr().(*net.TCPAddr).Port))
+		t.done = make(chan struct{})
+		t.closeOnce = &sync.Once{}
+		t.peers = map[string]*peer{}
+		t.connections = map[*connection]bool{}
+		t.seeds = map[string]*connection{}
+		t.entries = map[string]*gossipEntry{}
+		t.logger.Infoln("TCP is listening on ", listener.Addr(), " - address: ", t.address)
+
+		go t.acceptLoop(listener)
+		if !t.opts.DisableUdpDiscovery {
+			if err := t.startUdp(); err != nil {
+				t.logger.Warnln("TCP UDP discovery is not available -> ", err)
+			}
+		}
+		go t.loopWhileConnected(t.opts.GossipPeriod, t.gossipRound)
+		endChan <- nil
+	}()
+	return endChan
+}
+
+// publicHost return the host announced to the other nodes.
+func (t *TcpTransporter) publicHost() string {
+	if t.opts.PublicHost != "" {
+		return t.opts.PublicHost
+	}
+	if ip := net.ParseIP(t.opts.Host); t.opts.Host != "" && (ip == nil || !ip.IsUnspecified()) {
+		return t.opts.Host
+	}
+	addresses, err := net.InterfaceAddrs()
+	if err == nil {
+		for _, address := range addresses {
+			if network, isIP := address.(*net.IPNet); isIP && !network.IP.IsLoopback() && network.IP.To4() != nil {
+				return network.IP.String()
+			}
+		}
+	}
+	return "127.0.0.1"
+}
+
+func (t *TcpTransporter) loopWhileConnected(interval time.Duration, callback func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+			callback()
+		}
+	}
+}
+
+func (t *TcpTransporter) acceptLoop(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-t.done:
+			default:
+				t.logger.Errorln("TCP accept error -> ", err)
+			}
+			return
+		}
+		go t.readLoop(t.addConnection(conn, false))
+	}
+}
+
+func (t *TcpTransporter) Disconnect() chan error {
+	errChan := make(chan error)
+	go func() {
+		if t.closeOnce != nil {
+			t.closeOnce.Do(t.close)
+		}
+		errChan <- nil
+	}()
+	return errChan
+}
+
+// close stop the loops and close the listeners and all the connections.
+func (t *TcpTransporter) close() {
+	close(t.done)
+	t.listener.Close()
+	if t.udp != nil {
+		t.udp.Close()
+	}
+	t.mutex.Lock()
+	for connection := range t.connections {
+		connection.conn.Close()
+	}
+	t.peers = map[string]*peer{}
+	t.mutex.Unlock()
+	t.logger.Infoln("TCP is disconnected")
+}
+
+func topicName(command, nodeID string) string {
+	if nodeID != "" {
+		return command + "." + nodeID
+	}
+	return command
+}
+
+func (t *TcpTransporter) Subscribe(command, nodeID string, handler transit.TransportHandler) {
+	topic := topicName(command, nodeID)
+	t.handlersMutex.Lock()
+	t.handlers[topic] = append(t.handlers[topic], handler)
+	t.handlersMutex.Unlock()
+}
+
+// dispatch deliver the packet to the handlers subscribed to the command, targeted to this node when nodeID is set.
+func (t *TcpTransporter) dispatch(command, nodeID string, data []byte) {
+	t.handlersMutex.RLock()
+	handlers := t.handlers[topicName(command, nodeID)]
+	t.handlersMutex.RUnlock()
+	if len(handlers) == 0 {
+		return
+	}
+	message := t.serializer.BytesToPayload(&data)
+	t.logger.Debugf("Incoming %s packet from '%s'", command, message.Get("sender").String())
+	for _, handler := range handlers {
+		go handler(message)
+	}
+}
+
+// Publish send the packet to the target node over TCP. Broadcasted INFO and HEARTBEAT packets are spread
+// by the gossip rounds, the other broadcasted packets are sent to all known nodes.
+func (t *TcpTransporter) Publish(command, nodeID string, message nucleo.Payload) {
+	data := t.serializer.PayloadToBytes(message)
+	if nodeID != "" {
+		if err := t.send(nodeID, framePacket, packetBody(command, data)); err != nil {
+			t.logger.Warnf("TCP Publish - Can't send command: %s, nodeID: %s, error: %s", command, nodeID, err)
+		}
+		return
+	}
+	if command == "INFO" || command == "HEARTBEAT" {
+		t.updateLocalEntry(command, data)
+		return
+	}
+	waitGroup := sync.WaitGroup{}
+	for _, targetNodeID := range t.peerIDs() {
+		waitGroup.Add(1)
+		go func(targetNodeID string) {
+			defer waitGroup.Done()
+			if err := t.send(targetNodeID, frameBroadcast, packetBody(command, data)); err != nil {
+				t.logger.Debugf("TCP Publish - Can't send command: %s, nodeID: %s, error: %s", command, targetNodeID, err)
+			}
+		}(targetNodeID)
+	}
+	waitGroup.Wait()
+}
+
+func (t *TcpTransporter) SetPrefix(prefix string) {
+	t.prefix = prefix
+}
+
+func (t *TcpTransporter) SetNodeID(nodeID string) {
+	t.nodeID = nodeID
+}
+
+func (t *TcpTransporter) SetSerializer(serializer serializer.Serializer) {
+	t.serializer = serializer
+}
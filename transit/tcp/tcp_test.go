@@ -0,0 +1,168 @@
+package tcp
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Bendomey/nucleo-go"
+	"github.com/Bendomey/nucleo-go/payload"
+	"github.com/Bendomey/nucleo-go/serializer"
+	log "github.com/sirupsen/logrus"
+)
+
+// createTestTransporter connect a transporter on a random local port, discovering the other nodes with the seeds.
+// It announces itself with an INFO packet, spread by the gossip rounds.
+func createTestTransporter(t *testing.T, nodeID, prefix string, nodeTimeout time.Duration, seeds ...string) *TcpTransporter {
+	logger := log.WithField("transport", "tcp-test")
+	transporter := CreateTcpTransporter(TcpOptions{
+		Host:                "127.0.0.1",
+		Seeds:               seeds,
+		DisableUdpDiscovery: true,
+		GossipPeriod:        20 * time.Millisecond,
+		NodeTimeout:         nodeTimeout,
+		Logger:              logger,
+		Serializer:          serializer.CreateJSONSerializer(logger),
+	}).(*TcpTransporter)
+	transporter.SetPrefix(prefix)
+	transporter.SetNodeID(nodeID)
+	transporter.Subscribe("INFO", "", func(message nucleo.Payload) {})
+	if err := <-transporter.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { <-transporter.Disconnect() })
+	transporter.Publish("INFO", "", payload.New(map[string]interface{}{"sender": nodeID}))
+	return transporter
+}
+
+// expectPeers wait until the transporter knows exactly the given nodes.
+func expectPeers(t *testing.T, transporter *TcpTransporter, nodeIDs ...string) {
+	t.Helper()
+	sort.Strings(nodeIDs)
+	var peers []string
+	for start := time.Now(); time.Since(start) < 2*time.Second; time.Sleep(10 * time.Millisecond) {
+		peers = transporter.peerIDs()
+		sort.Strings(peers)
+		if strings.Join(peers, ",") == strings.Join(nodeIDs, ",") {
+			return
+		}
+	}
+	t.Fatalf("expected %s to know %v, got %v", transporter.nodeID, nodeIDs, peers)
+}
+
+func receiver(transporter *TcpTransporter, command, nodeID string) chan nucleo.Payload {
+	received := make(chan nucleo.Payload, 10)
+	transporter.Subscribe(command, nodeID, func(message nucleo.Payload) {
+		received <- message
+	})
+	return received
+}
+
+func expectMessage(t *testing.T, name string, received chan nucleo.Payload, sender string) {
+	t.Helper()
+	select {
+	case message := <-received:
+		if message.Get("sender").String() != sender {
+			t.Fatalf("%s: expected a packet from %s, got %v", name, sender, message.Value())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("%s: expected a packet from %s", name, sender)
+	}
+}
+
+func expectNoMessage(t *testing.T, name string, received chan nucleo.Payload) {
+	t.Helper()
+	select {
+	case message := <-received:
+		t.Fatalf("%s: expected no packet, got %v", name, message.Value())
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestHelloWithOtherPrefixIsRejected(t *testing.T) {
+	first := createTestTransporter(t, "node-1", "MOL-test", time.Minute)
+	other := createTestTransporter(t, "node-2", "MOL-other", time.Minute, first.address)
+	same := createTestTransporter(t, "node-3", "MOL-test", time.Minute, first.address)
+
+	expectPeers(t, first, "node-3")
+	expectPeers(t, same, "node-1")
+	time.Sleep(100 * time.Millisecond)
+	expectPeers(t, other)
+	expectPeers(t, first, "node-3")
+}
+
+func TestTargetedAndBroadcastDelivery(t *testing.T) {
+	first := createTestTransporter(t, "node-1", "MOL-test", time.Minute)
+	second := createTestTransporter(t, "node-2", "MOL-test", time.Minute, first.address)
+	third := createTestTransporter(t, "node-3", "MOL-test", time.Minute, first.address)
+	// the second and third nodes only know each other by gossip
+	expectPeers(t, second, "node-1", "node-3")
+	expectPeers(t, third, "node-1", "node-2")
+
+	requests := map[string]chan nucleo.Payload{}
+	events := map[string]chan nucleo.Payload{}
+	for _, transporter := range []*TcpTransporter{first, second, third} {
+		requests[transporter.nodeID] = receiver(transporter, "REQ", transporter.nodeID)
+		events[transporter.nodeID] = receiver(transporter, "EVENT", "")
+	}
+
+	second.Publish("REQ", "node-3", payload.New(map[string]interface{}{"sender": "node-2"}))
+	expectMessage(t, "targeted packet", requests["node-3"], "node-2")
+	expectNoMessage(t, "targeted packet to another node", requests["node-1"])
+	expectNoMessage(t, "targeted packet to another node", requests["node-2"])
+
+	first.Publish("EVENT", "", payload.New(map[string]interface{}{"sender": "node-1"}))
+	expectMessage(t, "broadcasted packet", events["node-2"], "node-1")
+	expectMessage(t, "broadcasted packet", events["node-3"], "node-1")
+	expectNoMessage(t, "broadcasted packet to the sender", events["node-1"])
+}
+
+func TestNodeRemovedOnDisconnect(t *testing.T) {
+	first := createTestTransporter(t, "node-1", "MOL-test", time.Minute)
+	second := createTestTransporter(t, "node-2", "MOL-test", time.Minute, first.address)
+	expectPeers(t, first, "node-2")
+	disconnected := receiver(first, "DISCONNECT", "")
+
+	second.Publish("DISCONNECT", "", payload.New(map[string]interface{}{"sender": "node-2"}))
+	<-second.Disconnect()
+	expectMessage(t, "disconnect packet", disconnected, "node-2")
+	expectPeers(t, first)
+
+	// the entries of the removed node are kept without packet, so gossip does not bring it back
+	first.mutex.Lock()
+	defer first.mutex.Unlock()
+	entry, exists := first.entries["INFO.node-2"]
+	if !exists || entry.Packet != nil {
+		t.Fatalf("expected the INFO entry of the removed node to be kept without packet, got %+v", entry)
+	}
+}
+
+func TestNodeRemovedOnTimeout(t *testing.T) {
+	first := createTestTransporter(t, "node-1", "MOL-test", 100*time.Millisecond)
+	second := createTestTransporter(t, "node-2", "MOL-test", time.Minute, first.address)
+	expectPeers(t, first, "node-2")
+
+	// the node stops without sending DISCONNECT
+	<-second.Disconnect()
+	expectPeers(t, first)
+}
+
+func TestDisconnectTwice(t *testing.T) {
+	transporter := createTestTransporter(t, "node-1", "MOL-test", time.Minute)
+	for attempt := 0; attempt < 2; attempt++ {
+		select {
+		case err := <-transporter.Disconnect():
+			if err != nil {
+				t.Fatalf("expected disconnect %d to succeed, got %s", attempt, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected disconnect %d to return", attempt)
+		}
+	}
+
+	notConnected := CreateTcpTransporter(TcpOptions{}).(*TcpTransporter)
+	if err := <-notConnected.Disconnect(); err != nil {
+		t.Fatalf("expected disconnect without connection to succeed, got %s", err)
+	}
+}
@@ -0,0 +1,76 @@
+package tcp
+
+import (
+	"encoding/json"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// announcement is the UDP datagram sent periodically by each node so the others can find its TCP address.
+type announcement struct {
+	Prefix  string `json:"prefix"`
+	NodeID  string `json:"nodeID"`
+	Address string `json:"address"`
+}
+
+// startUdp listen for the announcements of the other nodes on the multicast group (or the broadcast address)
+// and start announcing the local node.
+func (t *TcpTransporter) startUdp() error {
+	ip := net.ParseIP(t.opts.UdpAddress)
+	if ip == nil {
+		return errors.New("invalid UDP address: " + t.opts.UdpAddress)
+	}
+	group := &net.UDPAddr{IP: ip, Port: t.opts.UdpPort}
+	var conn *net.UDPConn
+	var err error
+	if ip.IsMulticast() {
+		conn, err = net.ListenMulticastUDP("udp4", nil, group)
+	} else {
+		conn, err = net.ListenUDP("udp4", &net.UDPAddr{Port: t.opts.UdpPort})
+	}
+	if err != nil {
+		return err
+	}
+	t.udp = conn
+	go t.udpReadLoop(conn)
+	go t.announce(conn, group)
+	return nil
+}
+
+func (t *TcpTransporter) announce(conn *net.UDPConn, group *net.UDPAddr) {
+	data, _ := json.Marshal(announcement{Prefix: t.prefix, NodeID: t.nodeID, Address: t.address})
+	send := func() {
+		if _, err := conn.WriteToUDP(data, group); err != nil {
+			t.logger.Debugln("TCP UDP announcement error -> ", err)
+		}
+	}
+	send()
+	t.loopWhileConnected(t.opts.UdpPeriod, send)
+}
+
+func (t *TcpTransporter) udpReadLoop(conn *net.UDPConn) {
+	buffer := make([]byte, 2048)
+	for {
+		size, _, err := conn.ReadFromUDP(buffer)
+		if err != nil {
+			select {
+			case <-t.done:
+				return
+			default:
+			}
+			t.logger.Debugln("TCP UDP read error -> ", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		remote := announcement{}
+		if err := json.Unmarshal(buffer[:size], &remote); err != nil || remote.Prefix != t.prefix || remote.NodeID == t.nodeID {
+			continue
+		}
+		if t.addPeer(remote.NodeID, remote.Address, nil) {
+			t.logger.Debugln("TCP discovered node ", remote.NodeID, " - address: ", remote.Address)
+			go t.gossipWith(remote.NodeID, gossipMessage{Digest: t.digest()})
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package transit
 
 import (
+	"time"
+
 	"github.com/Bendomey/nucleo-go"
 	"github.com/Bendomey/nucleo-go/serializer"
 )
@@ -12,6 +14,8 @@ type ValidateMsgFunc func(nucleo.Payload) bool
 type Transit interface {
 	Emit(nucleo.BrokerContext)
 	Request(nucleo.BrokerContext) chan nucleo.Payload
+	//CancelRequest discards the pending request of the context, its result channel receives a cancellation error.
+	CancelRequest(nucleo.BrokerContext)
 	Connect() chan error
 	Disconnect() chan error
 	DiscoverNode(nodeID string)
@@ -19,6 +23,9 @@ type Transit interface {
 	//DiscoverNodes checks if there are neighbours and return true if any are found ;).
 	DiscoverNodes() chan bool
 	SendHeartbeat()
+
+	//Ping sends a PING to the node and returns the round trip (elapsedTime) and clock difference (timeDiff) in milliseconds.
+	Ping(nodeID string, timeout time.Duration) chan nucleo.Payload
 }
 
 type Transport interface {